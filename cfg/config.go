@@ -26,6 +26,7 @@ type Config struct {
 type DirConfig struct {
 	Config       string `yaml:"config" envconfig:"DIR_CONFIG"`
 	Schemas      string `yaml:"schemas" envconfig:"DIR_SCHEMAS"`
+	Templates    string `yaml:"templates" envconfig:"DIR_TEMPLATES"`
 	Environments string `yaml:"environments" envconfig:"DIR_ENVIRONMENTS"`
 }
 
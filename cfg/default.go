@@ -22,6 +22,7 @@ var DefaultConfig = Config{
 	Dir: DirConfig{
 		Config:       "./",
 		Schemas:      "./schemas",
+		Templates:    "./templates",
 		Environments: "./environments",
 	},
 	Log: LogConfig{
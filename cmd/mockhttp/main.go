@@ -15,7 +15,11 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"os"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -24,6 +28,78 @@ import (
 
 func main() {
 	port := flag.Int("port", 9000, "port for the mock server")
+	mappings := flag.String("mappings", "",
+		"directory with mapping files (json or yaml) to preload, or to save in record mode")
+	record := flag.String("record", "", "upstream URL to proxy and record as mapping files")
+	redactHeaders := flag.String("redact-headers", "Authorization,Cookie,Set-Cookie",
+		"comma-separated headers to redact in record mode")
+	redactJSON := flag.String("redact-json", "",
+		"comma-separated JSON properties (gjson paths) to redact in record mode")
+	matchQuery := flag.Bool("match-query", true, "match the query params of recorded requests")
+	matchHeaders := flag.String("match-headers", "",
+		"comma-separated headers to match in recorded requests")
+	matchBody := flag.Bool("match-body", false, "match the body of recorded requests")
+	upstream := flag.String("upstream", "", "upstream URL to proxy the unmatched requests")
+	useTLS := flag.Bool("tls", false, "serve HTTPS with a generated certificate for localhost")
+	tlsCert := flag.String("tls-cert", "", "path to the server certificate (PEM) to serve HTTPS")
+	tlsKey := flag.String("tls-key", "", "path to the server key (PEM) to serve HTTPS")
+	tlsClientCA := flag.String("tls-client-ca", "",
+		"path to the CA certificates (PEM) to require and verify client certificates")
+	tlsCertOut := flag.String("tls-cert-out", "",
+		"path to write the server certificate (PEM), e.g. the generated one, to be trusted")
+	journalSize := flag.Int("journal-size", http.DefaultJournalSize,
+		"maximum number of received requests in the journal (negative to disable it)")
+	flag.Parse()
 	mock := http.NewServer(*port)
-	log.Fatal(mock.Start())
+	mock.SetJournalSize(*journalSize)
+	if *useTLS || *tlsCert != "" || *tlsKey != "" || *tlsClientCA != "" {
+		mock.TLS = &http.TLSOptions{Certificate: *tlsCert, Key: *tlsKey, ClientCA: *tlsClientCA}
+	}
+	if err := mock.SetUpstream(*upstream); err != nil {
+		log.Fatal(err)
+	}
+	switch {
+	case *record != "":
+		options := http.RecordOptions{
+			Upstream:      *record,
+			Dir:           *mappings,
+			RedactHeaders: splitList(*redactHeaders),
+			RedactJSON:    splitList(*redactJSON),
+			Match: http.MatchOptions{
+				Query:   *matchQuery,
+				Headers: splitList(*matchHeaders),
+				Body:    *matchBody,
+			},
+		}
+		if err := mock.Record(options); err != nil {
+			log.Fatal(err)
+		}
+	case *mappings != "":
+		if err := mock.LoadMappings(*mappings); err != nil {
+			log.Fatal(err)
+		}
+		go mock.WatchMappings(context.Background(), *mappings, time.Second)
+	}
+	if err := mock.Listen(); err != nil {
+		log.Fatal(err)
+	}
+	if *tlsCertOut != "" && mock.TLS != nil {
+		if err := os.WriteFile(*tlsCertOut, mock.CertificatePEM(), 0o600); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := mock.Start(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// splitList splits a comma-separated list.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -44,6 +44,15 @@ func GetContext(ctx context.Context) *Context {
 	return ctx.Value(contextKey).(*Context)
 }
 
+// LookupContext returns the Context stored in context, and whether it was initialized.
+func LookupContext(ctx context.Context) (*Context, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	c, ok := ctx.Value(contextKey).(*Context)
+	return c, ok
+}
+
 // Get returns an element from Context.Ctx.
 // If the value does not exist, it returns nil.
 func (c *Context) Get(key string) interface{} {
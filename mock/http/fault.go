@@ -0,0 +1,155 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Types of faults.
+const (
+	// FaultConnectionReset closes the connection with a TCP reset without a response.
+	FaultConnectionReset = "connectionReset"
+	// FaultEmptyReply closes the connection without a response.
+	FaultEmptyReply = "emptyReply"
+	// FaultMalformedResponse writes an invalid HTTP response and closes the connection.
+	FaultMalformedResponse = "malformedResponse"
+	// FaultError responds with an error status code instead of the response.
+	FaultError = "error"
+)
+
+// malformedResponse is the payload of the malformed response fault.
+const malformedResponse = "HTTP/1.1 ??? Malformed\r\nContent-Length: invalid\r\n\r\n\x00\xff"
+
+// Fault configures a failure of the mock server instead of the response.
+type Fault struct {
+	// Type of the fault: connectionReset, emptyReply, malformedResponse or error.
+	Type string `json:"type"`
+	// Status is the status code of the error fault (500 by default).
+	Status int `json:"status,omitempty"`
+	// Rate is the percentage (0-100] of requests with the fault. If 0, all the requests fail.
+	Rate float64 `json:"rate,omitempty"`
+}
+
+// Validate the fault.
+func (f *Fault) Validate() error {
+	switch f.Type {
+	case FaultConnectionReset, FaultEmptyReply, FaultMalformedResponse, FaultError:
+	default:
+		return fmt.Errorf("invalid fault type '%s'", f.Type)
+	}
+	if f.Status != 0 && http.StatusText(f.Status) == "" {
+		return fmt.Errorf("invalid fault status code %d", f.Status)
+	}
+	if f.Rate < 0 || f.Rate > 100 {
+		return fmt.Errorf("invalid fault rate %v: it must be a percentage", f.Rate)
+	}
+	return nil
+}
+
+// applies returns true if the fault applies to a request according to the fault rate.
+func (f *Fault) applies() bool {
+	// #nosec G404
+	return f != nil && (f.Rate == 0 || rand.Float64()*100 < f.Rate)
+}
+
+// inject the fault in the response. It returns an error if the connection could not be
+// hijacked (e.g. HTTP/2), and then the request is aborted.
+func (f *Fault) inject(w http.ResponseWriter) error {
+	if f.Type == FaultError {
+		status := f.Status
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		w.WriteHeader(status)
+		return nil
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return fmt.Errorf("failed hijacking the connection: %w", err)
+	}
+	defer conn.Close()
+	switch f.Type {
+	case FaultConnectionReset:
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			return tcpConn.SetLinger(0)
+		}
+	case FaultMalformedResponse:
+		_, err = conn.Write([]byte(malformedResponse))
+	}
+	return err
+}
+
+// delay waits the latency plus a random jitter of the mockRequest. A negative latency never
+// responds until the client closes the request. It returns false if the request was closed.
+func delay(r *http.Request, latency, jitter int) bool {
+	if latency < 0 {
+		<-r.Context().Done()
+		return false
+	}
+	d := time.Duration(latency) * time.Millisecond
+	if jitter > 0 {
+		// #nosec G404
+		d += time.Duration(rand.IntN(jitter)) * time.Millisecond
+	}
+	if d == 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// trickle writes the body at a rate of bytes per second, flushing every 100 milliseconds.
+func trickle(w http.ResponseWriter, r *http.Request, body []byte, bytesPerSecond int) error {
+	const interval = 100 * time.Millisecond
+	chunk := max(bytesPerSecond*int(interval)/int(time.Second), 1)
+	controller := http.NewResponseController(w)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for len(body) > 0 {
+		n := min(chunk, len(body))
+		if _, err := w.Write(body[:n]); err != nil {
+			return err
+		}
+		if err := controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		body = body[n:]
+		if len(body) == 0 {
+			break
+		}
+		select {
+		case <-ticker.C:
+		case <-r.Context().Done():
+			return r.Context().Err()
+		}
+	}
+	return nil
+}
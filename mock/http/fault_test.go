@@ -0,0 +1,159 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockFaults(t *testing.T) {
+	tcs := []struct {
+		name           string
+		mockRequest    MockRequest
+		expectedStatus int
+		wantErr        bool
+	}{
+		{
+			name:        "connection reset",
+			mockRequest: MockRequest{Fault: &Fault{Type: FaultConnectionReset}},
+			wantErr:     true,
+		},
+		{
+			name:        "empty reply",
+			mockRequest: MockRequest{Fault: &Fault{Type: FaultEmptyReply}},
+			wantErr:     true,
+		},
+		{
+			name:        "malformed response",
+			mockRequest: MockRequest{Fault: &Fault{Type: FaultMalformedResponse}},
+			wantErr:     true,
+		},
+		{
+			name:           "error",
+			mockRequest:    MockRequest{Fault: &Fault{Type: FaultError}},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "error with status",
+			mockRequest: MockRequest{
+				Fault: &Fault{Type: FaultError, Status: http.StatusServiceUnavailable},
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:        "no response",
+			mockRequest: MockRequest{Latency: -1},
+			wantErr:     true,
+		},
+		{
+			name:           "jitter",
+			mockRequest:    MockRequest{Latency: 1, Jitter: 10},
+			expectedStatus: http.StatusOK,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			_, server := newTestServer()
+			defer server.Close()
+			mockRequest := tc.mockRequest
+			mockRequest.Request = Request{Method: http.MethodGet, Path: "/fault"}
+			mockRequest.Response = Response{Status: http.StatusOK, Body: "ok"}
+			require.NoError(t, sendMockRequest(ctx, server.URL, &mockRequest))
+
+			client := &http.Client{Timeout: 500 * time.Millisecond}
+			resp, err := client.Get(server.URL + "/fault")
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, tc.expectedStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestMockFaultRate(t *testing.T) {
+	ctx := context.Background()
+	_, server := newTestServer()
+	defer server.Close()
+	mockRequest := &MockRequest{
+		Permanent: true,
+		Request:   Request{Method: http.MethodGet, Path: "/rate"},
+		Response:  Response{Status: http.StatusOK},
+		Fault:     &Fault{Type: FaultError, Rate: 50},
+	}
+	require.NoError(t, sendMockRequest(ctx, server.URL, mockRequest))
+	failures := 0
+	for i := 0; i < 200; i++ {
+		resp, err := http.Get(server.URL + "/rate")
+		require.NoError(t, err)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusInternalServerError {
+			failures++
+		}
+	}
+	require.InDelta(t, 100, failures, 40)
+}
+
+func TestMockBytesPerSecond(t *testing.T) {
+	ctx := context.Background()
+	_, server := newTestServer()
+	defer server.Close()
+	mockRequest := &MockRequest{
+		BytesPerSecond: 20,
+		Request:        Request{Method: http.MethodGet, Path: "/slow"},
+		Response:       Response{Status: http.StatusOK, Body: "0123456789"},
+	}
+	require.NoError(t, sendMockRequest(ctx, server.URL, mockRequest))
+	start := time.Now()
+	resp, err := http.Get(server.URL + "/slow")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", string(body))
+	require.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestFaultValidate(t *testing.T) {
+	tcs := []struct {
+		name    string
+		fault   Fault
+		wantErr bool
+	}{
+		{name: "valid", fault: Fault{Type: FaultConnectionReset, Rate: 10}},
+		{name: "invalid type", fault: Fault{Type: "timeout"}, wantErr: true},
+		{name: "invalid status", fault: Fault{Type: FaultError, Status: 999}, wantErr: true},
+		{name: "invalid rate", fault: Fault{Type: FaultError, Rate: 120}, wantErr: true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.fault.Validate()
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
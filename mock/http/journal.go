@@ -0,0 +1,127 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultJournalSize is the maximum number of requests in the journal when
+	// Journal.MaxRequests is not set.
+	DefaultJournalSize = 1000
+	// MaxJournalBodySize is the maximum size of the request bodies stored in the journal.
+	// Bigger bodies are truncated.
+	MaxJournalBodySize = 1 << 20
+)
+
+// ReceivedRequest is a request received by the mock server, as recorded in the journal.
+type ReceivedRequest struct {
+	Method  string              `json:"method"`
+	Path    string              `json:"path"`
+	Query   map[string][]string `json:"query,omitempty"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    string              `json:"body,omitempty"`
+	// BodyTruncated is true if the body was truncated to MaxJournalBodySize bytes.
+	BodyTruncated bool `json:"bodyTruncated,omitempty"`
+	// Matched is true if the request matched a MockRequest.
+	Matched bool      `json:"matched"`
+	Time    time.Time `json:"time"`
+}
+
+// Journal records the requests received by the mock server.
+type Journal struct {
+	// MaxRequests is the maximum number of requests in the journal. When it is reached,
+	// the oldest requests are dropped. If 0, DefaultJournalSize is used.
+	// If negative, the requests are not recorded.
+	MaxRequests int
+	requests    []ReceivedRequest
+	mutex       sync.Mutex
+}
+
+// Record a request in the journal. The request body is read and replaced by a new reader
+// with the same content. The oldest requests are dropped when the journal is full.
+func (j *Journal) Record(r *http.Request, matched bool) ReceivedRequest {
+	body := readBody(r)
+	truncated := len(body) > MaxJournalBodySize
+	if truncated {
+		body = body[:MaxJournalBodySize]
+	}
+	received := ReceivedRequest{
+		Method:        r.Method,
+		Path:          r.URL.Path,
+		Query:         r.URL.Query(),
+		Headers:       r.Header.Clone(),
+		Body:          string(body),
+		BodyTruncated: truncated,
+		Matched:       matched,
+		Time:          time.Now(),
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	maxRequests := j.MaxRequests
+	if maxRequests == 0 {
+		maxRequests = DefaultJournalSize
+	}
+	if maxRequests < 0 {
+		return received
+	}
+	j.requests = append(j.requests, received)
+	if len(j.requests) > maxRequests {
+		j.requests = j.requests[len(j.requests)-maxRequests:]
+	}
+	return received
+}
+
+// Requests returns the received requests filtered by method and path (empty for any).
+// The path is matched as the path of a MockRequest filter (e.g. "/users/<*>" or "/users/{id}").
+func (j *Journal) Requests(method, path string) []ReceivedRequest {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	requests := []ReceivedRequest{}
+	for _, r := range j.requests {
+		if method != "" && r.Method != method {
+			continue
+		}
+		if _, ok := PathParams(Request{Path: path}, r.Path); path != "" && !ok {
+			continue
+		}
+		requests = append(requests, r)
+	}
+	return requests
+}
+
+// SetMaxRequests configures the maximum number of requests in the journal,
+// dropping the oldest requests if there are more.
+func (j *Journal) SetMaxRequests(maxRequests int) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.MaxRequests = maxRequests
+	switch {
+	case maxRequests < 0:
+		j.requests = nil
+	case maxRequests > 0 && len(j.requests) > maxRequests:
+		j.requests = j.requests[len(j.requests)-maxRequests:]
+	}
+}
+
+// Clean removes all the requests from the journal.
+func (j *Journal) Clean() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.requests = nil
+}
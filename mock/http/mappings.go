@@ -0,0 +1,163 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mappingExtensions are the file extensions of the mapping files.
+var mappingExtensions = map[string]bool{".json": true, ".yml": true, ".yaml": true}
+
+// LoadMappings loads the mockRequests of the mapping files (json or yaml) in a directory and
+// its subdirectories. A mapping file contains a mockRequest or a list of mockRequests.
+func LoadMappings(dir string) ([]*MockRequest, error) {
+	files, err := mappingFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	var mockRequests []*MockRequest
+	for _, file := range files {
+		fileMockRequests, err := loadMappingFile(file)
+		if err != nil {
+			return nil, err
+		}
+		mockRequests = append(mockRequests, fileMockRequests...)
+	}
+	return mockRequests, nil
+}
+
+// mappingFiles returns the sorted list of mapping files in a directory.
+func mappingFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && mappingExtensions[strings.ToLower(filepath.Ext(path))] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed reading mappings directory '%s': %w", dir, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func loadMappingFile(file string) ([]*MockRequest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading mapping file '%s': %w", file, err)
+	}
+	// yaml is a superset of json, and it is converted to json to use the json tags
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed parsing mapping file '%s': %w", file, err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+	if data, err = json.Marshal(raw); err != nil {
+		return nil, fmt.Errorf("failed converting mapping file '%s': %w", file, err)
+	}
+	var mockRequests []*MockRequest
+	if _, ok := raw.([]interface{}); ok {
+		err = json.Unmarshal(data, &mockRequests)
+	} else {
+		var mockRequest MockRequest
+		err = json.Unmarshal(data, &mockRequest)
+		mockRequests = []*MockRequest{&mockRequest}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid mapping file '%s': %w", file, err)
+	}
+	for i, mockRequest := range mockRequests {
+		if err := mockRequest.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid mockRequest %d of mapping file '%s': %w", i, file, err)
+		}
+		mockRequest.mapping = file
+	}
+	return mockRequests, nil
+}
+
+// mappingsVersion returns a fingerprint of the mapping files (names, sizes and modification
+// times) to detect changes.
+func mappingsVersion(dir string) (string, error) {
+	files, err := mappingFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	var version strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&version, "%s:%d:%d;", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return version.String(), nil
+}
+
+// LoadMappings replaces the mockRequests loaded from mapping files with the mockRequests of
+// the mapping files in a directory. The mockRequests pushed with the API are kept.
+func (s *Server) LoadMappings(dir string) error {
+	mockRequests, err := LoadMappings(dir)
+	if err != nil {
+		return err
+	}
+	s.mockRequests.ReplaceMappings(mockRequests)
+	s.logger.Infof("Loaded %d mockRequests from mappings directory '%s'", len(mockRequests), dir)
+	return nil
+}
+
+// WatchMappings reloads the mapping files of a directory when they change, checking them
+// at every interval until the context is done. If the mapping files are invalid,
+// the previous mockRequests are kept.
+func (s *Server) WatchMappings(ctx context.Context, dir string, interval time.Duration) {
+	version, _ := mappingsVersion(dir)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		current, err := mappingsVersion(dir)
+		if err != nil {
+			s.logger.Errorf("Failed checking mappings directory '%s': %s", dir, err)
+			continue
+		}
+		if current == version {
+			continue
+		}
+		version = current
+		if err := s.LoadMappings(dir); err != nil {
+			s.logger.Errorf("Failed reloading mappings: %s", err)
+		}
+	}
+}
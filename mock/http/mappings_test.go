@@ -0,0 +1,168 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeMapping(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestLoadMappings(t *testing.T) {
+	tcs := []struct {
+		name     string
+		files    map[string]string
+		expected []string
+		err      bool
+	}{
+		{
+			name: "json and yaml",
+			files: map[string]string{
+				"users.json": `{"request": {"method": "GET", "path": "/users"}}`,
+				"sub/orders.yml": `
+- request:
+    method: GET
+    path: /orders
+  response:
+    status: 200
+- request:
+    path: /orders/{id}
+`,
+				"README.md": "ignored",
+			},
+			expected: []string{"/orders", "/orders/{id}", "/users"},
+		},
+		{
+			name:  "empty file",
+			files: map[string]string{"empty.yaml": ""},
+		},
+		{
+			name:  "invalid file",
+			files: map[string]string{"invalid.json": `{"request": `},
+			err:   true,
+		},
+		{
+			name:  "invalid mockRequest",
+			files: map[string]string{"invalid.yaml": "request:\n  method: GET\n"},
+			err:   true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				writeMapping(t, filepath.Join(dir, name), content)
+			}
+			mockRequests, err := LoadMappings(dir)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			paths := []string{}
+			for _, mockRequest := range mockRequests {
+				paths = append(paths, mockRequest.Request.Path)
+			}
+			require.ElementsMatch(t, tc.expected, paths)
+		})
+	}
+	_, err := LoadMappings(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+}
+
+func TestServerMappings(t *testing.T) {
+	s, server := newTestServer()
+	defer server.Close()
+	dir := t.TempDir()
+	writeMapping(t, filepath.Join(dir, "users.json"),
+		`{"request": {"path": "/users"}, "response": {"status": 201}}`)
+	require.NoError(t, s.LoadMappings(dir))
+	s.mockRequests.PushMockRequest(&MockRequest{Request: Request{Path: "/api"}})
+
+	listPaths := func() []string {
+		resp, err := http.Get(server.URL + "/_mock/requests")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var mockRequests []MockRequest
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&mockRequests))
+		paths := []string{}
+		for _, mockRequest := range mockRequests {
+			paths = append(paths, mockRequest.Request.Path)
+		}
+		return paths
+	}
+	require.ElementsMatch(t, []string{"/users", "/api"}, listPaths())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.WatchMappings(ctx, dir, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	writeMapping(t, filepath.Join(dir, "users.json"), `{"request": {"path": "/customers"}}`)
+	require.Eventually(t, func() bool {
+		paths := listPaths()
+		return len(paths) == 2 && paths[1] == "/customers"
+	}, 2*time.Second, 20*time.Millisecond)
+
+	// invalid mappings keep the previous mockRequests
+	writeMapping(t, filepath.Join(dir, "invalid.json"), `{"request": `)
+	time.Sleep(100 * time.Millisecond)
+	require.ElementsMatch(t, []string{"/api", "/customers"}, listPaths())
+}
+
+func TestCleanKeepsMappings(t *testing.T) {
+	s, server := newTestServer()
+	defer server.Close()
+	dir := t.TempDir()
+	writeMapping(t, filepath.Join(dir, "users.json"),
+		`{"request": {"path": "/users"}, "response": {"status": 201}}`)
+	require.NoError(t, s.LoadMappings(dir))
+
+	for _, command := range []struct{ method, path string }{
+		{method: http.MethodPost, path: "/_mock/reset"},
+		{method: http.MethodDelete, path: "/_mock/requests"},
+	} {
+		t.Run(command.method+" "+command.path, func(t *testing.T) {
+			s.mockRequests.PushMockRequest(&MockRequest{Request: Request{Path: "/api"}})
+			// the mapping is not permanent and it is consumed by the first request
+			status, _ := send(t, http.MethodGet, server.URL+"/users", "", nil)
+			require.Equal(t, http.StatusCreated, status)
+			require.Len(t, s.mockRequests.MockRequests(), 1)
+
+			req, err := http.NewRequest(command.method, server.URL+command.path, nil)
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			mockRequests := s.mockRequests.MockRequests()
+			require.Len(t, mockRequests, 1)
+			require.Equal(t, "/users", mockRequests[0].Request.Path)
+		})
+	}
+}
@@ -14,28 +14,119 @@
 
 package http
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
 
 // MockRequest contains the instruction to configure the behavior of the HTTP mock server.
 // The document configures which request is going to be attended (e.g. the path and method)
 // and the response to be generated by the mock.
 type MockRequest struct {
 	// Permanent is true if the configuration is permanent.
-	// If permanent is false, the mockRequest is removed after matching the first request.
-	Permanent bool     `json:"permanent"`
-	Request   Request  `json:"request"`
-	Response  Response `json:"response"`
+	// If permanent is false, the mockRequest is removed after matching the first request
+	// (or after the last response of Responses).
+	Permanent bool `json:"permanent"`
+	// Priority of the mockRequest when several mockRequests match the same request.
+	// The mockRequest with the highest priority is selected, and then the oldest one.
+	Priority int      `json:"priority,omitempty"`
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+	// Responses is a sequence of responses returned in order, one per matched request.
+	// It overrides Response. The last response is repeated if the mockRequest is permanent.
+	Responses []Response `json:"responses,omitempty"`
+	// Scenario is the name of a state machine shared by several mockRequests.
+	// The state of a scenario is StateStarted until a mockRequest moves it to NewState.
+	Scenario string `json:"scenario,omitempty"`
+	// RequiredState is the state of the scenario required to match the mockRequest.
+	RequiredState string `json:"requiredState,omitempty"`
+	// NewState is the state of the scenario after the mockRequest responds.
+	NewState string `json:"newState,omitempty"`
 	// Latency is the duration in milliseconds to wait to deliver the response.
 	// If 0, there is no latency to apply.
 	// If negative, there will be no response (timeout simulation).
 	Latency int `json:"latency"`
+	// Jitter is the maximum random duration in milliseconds added to the latency.
+	Jitter int `json:"jitter,omitempty"`
+	// BytesPerSecond is the rate to write the response body. If 0, the body is written at once.
+	BytesPerSecond int `json:"bytesPerSecond,omitempty"`
+	// Fault is a failure of the mock server instead of the response.
+	Fault *Fault `json:"fault,omitempty"`
+	// Proxy forwards the request to the upstream of the server and modifies its response,
+	// instead of returning Response.
+	Proxy *ProxyResponse `json:"proxy,omitempty"`
+
+	// served is the number of requests matched by the mockRequest.
+	served int
+	// mapping is the file of the mockRequest if it was loaded from a mapping file.
+	mapping string
+}
+
+// StateStarted is the initial state of the scenarios.
+const StateStarted = "started"
+
+// Validate the mockRequest.
+func (m MockRequest) Validate() error {
+	if m.Scenario == "" && (m.RequiredState != "" || m.NewState != "") {
+		return errors.New("missing scenario of requiredState or newState")
+	}
+	if m.Jitter < 0 || m.BytesPerSecond < 0 {
+		return errors.New("negative jitter or bytesPerSecond")
+	}
+	if m.Fault != nil {
+		if err := m.Fault.Validate(); err != nil {
+			return err
+		}
+	}
+	if m.Proxy != nil {
+		if err := m.Proxy.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, resp := range append([]Response{m.Response}, m.Responses...) {
+		if err := resp.Validate(); err != nil {
+			return err
+		}
+	}
+	return m.Request.Validate()
 }
 
 // Request configures the filter for the request of the MockRequest.
+// All the configured filters must match the request.
 type Request struct {
-	Method  string              `json:"method,omitempty"`
-	Path    string              `json:"path,omitempty"`
+	Method string `json:"method,omitempty"`
+	// Path matches the request path literally, or any path with the same prefix if it ends
+	// with "<*>". It might contain path params (e.g. "/users/{id}") matching a path segment.
+	Path string `json:"path,omitempty"`
+	// PathRegex is a regular expression matching the whole request path.
+	// The named groups are path params (e.g. "/users/(?P<id>[0-9]+)").
+	PathRegex string `json:"pathRegex,omitempty"`
+	// Query contains the query params with the values that the request must contain.
+	Query   map[string][]string `json:"query,omitempty"`
 	Headers map[string][]string `json:"headers,omitempty"`
+	// JSON contains the properties (gjson paths) that the JSON request body must contain.
+	JSON map[string]interface{} `json:"json,omitempty"`
+	// BodyRegex is a regular expression that the request body must contain.
+	BodyRegex string `json:"bodyRegex,omitempty"`
+}
+
+// Validate the filter of the request.
+func (r Request) Validate() error {
+	if r.Path == "" && r.PathRegex == "" {
+		return errors.New("missing path or pathRegex")
+	}
+	if _, err := pathRegexp(r.Path); err != nil {
+		return fmt.Errorf("invalid path '%s': %w", r.Path, err)
+	}
+	if _, err := regexp.Compile(r.PathRegex); err != nil {
+		return fmt.Errorf("invalid pathRegex '%s': %w", r.PathRegex, err)
+	}
+	if _, err := regexp.Compile(r.BodyRegex); err != nil {
+		return fmt.Errorf("invalid bodyRegex '%s': %w", r.BodyRegex, err)
+	}
+	return nil
 }
 
 // Response configures which response if the request filter applies.
@@ -43,6 +134,27 @@ type Response struct {
 	Status  int                 `json:"status"`
 	Headers map[string][]string `json:"headers"`
 	Body    string              `json:"body"`
+	// Template is true if the body and headers contain tags with values of the request
+	// (e.g. "[path:id]" or "[json:user.name]") or generated values ("[uuid]").
+	Template bool `json:"template,omitempty"`
+}
+
+// Validate the tags of the response if it is a template.
+func (r Response) Validate() error {
+	if !r.Template {
+		return nil
+	}
+	if err := validateTemplate(r.Body); err != nil {
+		return fmt.Errorf("invalid template of the response body: %w", err)
+	}
+	for header, values := range r.Headers {
+		for _, value := range values {
+			if err := validateTemplate(value); err != nil {
+				return fmt.Errorf("invalid template of the response header '%s': %w", header, err)
+			}
+		}
+	}
+	return nil
 }
 
 func (m MockRequest) String() string {
@@ -0,0 +1,142 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+
+	"github.com/tidwall/sjson"
+)
+
+// ProxyResponse configures a mockRequest to forward the request to the upstream of the server
+// and modify the upstream response.
+type ProxyResponse struct {
+	// Status overrides the status code of the upstream response if not 0.
+	Status int `json:"status,omitempty"`
+	// Headers overrides the headers of the upstream response.
+	Headers map[string][]string `json:"headers,omitempty"`
+	// JSON contains the properties (gjson paths) set in the JSON body of the upstream response.
+	JSON map[string]interface{} `json:"json,omitempty"`
+}
+
+// Validate the proxy response.
+func (p ProxyResponse) Validate() error {
+	if p.Status != 0 && http.StatusText(p.Status) == "" {
+		return fmt.Errorf("invalid proxy status %d", p.Status)
+	}
+	return nil
+}
+
+// modify the upstream response.
+func (p *ProxyResponse) modify(resp *http.Response) error {
+	if p.Status != 0 {
+		resp.StatusCode = p.Status
+		resp.Status = fmt.Sprintf("%d %s", p.Status, http.StatusText(p.Status))
+	}
+	for header, values := range p.Headers {
+		resp.Header[http.CanonicalHeaderKey(header)] = values
+	}
+	if len(p.JSON) == 0 {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed reading the upstream response: %w", err)
+	}
+	for path, value := range p.JSON {
+		if body, err = sjson.SetBytes(body, path, value); err != nil {
+			return fmt.Errorf("failed setting JSON property '%s': %w", path, err)
+		}
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// proxyResponseKey is the context key of the ProxyResponse of a proxied request.
+type proxyResponseKey struct{}
+
+// newUpstreamProxy creates a reverse proxy to the upstream that modifies the responses
+// according to the ProxyResponse of the request context.
+func newUpstreamProxy(upstream string) (*httputil.ReverseProxy, error) {
+	u, err := url.Parse(upstream)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid upstream '%s'", upstream)
+	}
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(u)
+			if p, ok := r.In.Context().Value(proxyResponseKey{}).(*ProxyResponse); ok &&
+				len(p.JSON) > 0 {
+				// the JSON body is patched uncompressed
+				r.Out.Header.Del("Accept-Encoding")
+			}
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			p, ok := resp.Request.Context().Value(proxyResponseKey{}).(*ProxyResponse)
+			if !ok {
+				return nil
+			}
+			return p.modify(resp)
+		},
+	}, nil
+}
+
+// SetUpstream configures the upstream (e.g. https://api.example.com) where the requests not
+// matched by any mockRequest are forwarded, instead of responding 404. It is also the
+// upstream of the mockRequests with a ProxyResponse. An empty upstream disables it.
+func (s *Server) SetUpstream(upstream string) error {
+	var proxy *httputil.ReverseProxy
+	if upstream != "" {
+		var err error
+		if proxy, err = newUpstreamProxy(upstream); err != nil {
+			return err
+		}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.upstream = proxy
+	return nil
+}
+
+// proxy forwards the request to the upstream. The response is modified if proxyResponse
+// is not nil. It responds 404 if there is no upstream configured for unmatched requests,
+// or 502 if there is no upstream configured for a mockRequest with a ProxyResponse.
+func (s *Server) proxy(w http.ResponseWriter, r *http.Request, proxyResponse *ProxyResponse) {
+	s.mutex.Lock()
+	upstream := s.upstream
+	s.mutex.Unlock()
+	switch {
+	case upstream != nil && proxyResponse != nil:
+		ctx := context.WithValue(r.Context(), proxyResponseKey{}, proxyResponse)
+		upstream.ServeHTTP(w, r.WithContext(ctx))
+	case upstream != nil:
+		upstream.ServeHTTP(w, r)
+	case proxyResponse != nil:
+		s.logger.Errorf("Missing upstream to proxy the request to '%s'", r.URL.Path)
+		w.WriteHeader(http.StatusBadGateway)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetUpstream(t *testing.T) {
+	s := NewServer(0)
+	require.Error(t, s.SetUpstream("localhost:9000"))
+	require.NoError(t, s.SetUpstream("http://localhost:9000"))
+	require.NotNil(t, s.upstream)
+	require.NoError(t, s.SetUpstream(""))
+	require.Nil(t, s.upstream)
+}
+
+func TestProxyUpstream(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Upstream", "true")
+		fmt.Fprintf(w, `{"path": "%s", "user": {"name": "alice", "active": true}}`, r.URL.Path)
+	}))
+	defer upstream.Close()
+	s, server := newTestServer()
+	defer server.Close()
+
+	// mockRequest with a proxy response and without upstream
+	proxy := &ProxyResponse{
+		Status:  http.StatusAccepted,
+		Headers: map[string][]string{"x-patched": {"yes"}},
+		JSON:    map[string]interface{}{"user.active": false, "user.roles": []string{"admin"}},
+	}
+	s.mockRequests.PushMockRequest(&MockRequest{
+		Permanent: true,
+		Request:   Request{Path: "/users/1"},
+		Proxy:     proxy,
+	})
+	s.mockRequests.PushMockRequest(&MockRequest{
+		Permanent: true,
+		Request:   Request{Path: "/stub"},
+		Response:  Response{Status: http.StatusTeapot},
+	})
+	status, _ := send(t, http.MethodGet, server.URL+"/users/1", "", nil)
+	require.Equal(t, http.StatusBadGateway, status)
+	status, _ = send(t, http.MethodGet, server.URL+"/other", "", nil)
+	require.Equal(t, http.StatusNotFound, status)
+
+	require.NoError(t, s.SetUpstream(upstream.URL))
+	tcs := []struct {
+		name   string
+		path   string
+		status int
+		body   string
+	}{
+		{
+			name:   "unmatched request",
+			path:   "/other",
+			status: http.StatusOK,
+			body:   `{"path": "/other", "user": {"name": "alice", "active": true}}`,
+		},
+		{
+			name:   "modified response",
+			path:   "/users/1",
+			status: http.StatusAccepted,
+			body:   `{"path": "/users/1", "user": {"name": "alice", "active": false,"roles":["admin"]}}`,
+		},
+		{
+			name:   "stub",
+			path:   "/stub",
+			status: http.StatusTeapot,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			status, body := send(t, http.MethodGet, server.URL+tc.path, "", nil)
+			require.Equal(t, tc.status, status)
+			require.Equal(t, tc.body, body)
+		})
+	}
+	resp, err := http.Get(server.URL + "/users/1")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, "yes", resp.Header.Get("X-Patched"))
+	require.Equal(t, "true", resp.Header.Get("X-Upstream"))
+
+	other := s.journal.Requests(http.MethodGet, "/other")
+	require.Len(t, other, 2)
+	require.False(t, other[1].Matched)
+}
+
+func TestValidateProxyResponse(t *testing.T) {
+	mockRequest := MockRequest{Request: Request{Path: "/"}, Proxy: &ProxyResponse{Status: 1000}}
+	require.Error(t, mockRequest.Validate())
+	mockRequest.Proxy.Status = http.StatusOK
+	require.NoError(t, mockRequest.Validate())
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// mockURL returns the URL of an endpoint of the mock server API.
+func mockURL(server, endpoint string) (string, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint URL: %s: %w", server, err)
+	}
+	u.Path = path.Join(u.Path, endpoint)
+	return u.String(), nil
+}
+
+// sendMockCommand sends a request without body to an endpoint of the mock server API.
+func sendMockCommand(ctx context.Context, method, server, endpoint string,
+	query url.Values,
+) (*http.Response, error) {
+	u, err := mockURL(server, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating request to mock server: %w", err)
+	}
+	client, err := newMockClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.CloseIdleConnections()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed sending request to mock server: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code from mock server: %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// GetReceivedRequests returns the requests received by the mock server with a method and path
+// (empty for any). The path is matched as the path of a MockRequest filter.
+func GetReceivedRequests(ctx context.Context, server, method, httpPath string,
+) ([]ReceivedRequest, error) {
+	query := url.Values{}
+	if method != "" {
+		query.Set("method", method)
+	}
+	if httpPath != "" {
+		query.Set("path", httpPath)
+	}
+	resp, err := sendMockCommand(ctx, http.MethodGet, server, "/_mock/requests/received", query)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var requests []ReceivedRequest
+	if err := json.NewDecoder(resp.Body).Decode(&requests); err != nil {
+		return nil, fmt.Errorf("failed decoding the received requests: %w", err)
+	}
+	return requests, nil
+}
+
+// ValidateReceivedRequests validates the number of requests received by the mock server with
+// a method and path whose JSON body has the properties (nil to skip the body).
+func ValidateReceivedRequests(ctx context.Context, server string, count int, method,
+	httpPath string, props map[string]interface{},
+) error {
+	requests, err := GetReceivedRequests(ctx, server, method, httpPath)
+	if err != nil {
+		return err
+	}
+	var matched int
+	for _, r := range requests {
+		if matchJSONProperties([]byte(r.Body), props) {
+			matched++
+		}
+	}
+	if matched != count {
+		return fmt.Errorf("mismatch of requests '%s %s' received by the mock server: "+
+			"expected '%d', actual '%d'", method, httpPath, count, matched)
+	}
+	return nil
+}
+
+// ResetReceivedRequests cleans the journal of requests received by the mock server.
+func ResetReceivedRequests(ctx context.Context, server string) error {
+	resp, err := sendMockCommand(ctx, http.MethodDelete, server, "/_mock/requests/received", nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// GetScenarioStates returns the current state of the scenarios of the mock server.
+func GetScenarioStates(ctx context.Context, server string) (map[string]string, error) {
+	resp, err := sendMockCommand(ctx, http.MethodGet, server, "/_mock/scenarios", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var states map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&states); err != nil {
+		return nil, fmt.Errorf("failed decoding the scenario states: %w", err)
+	}
+	return states, nil
+}
+
+// ValidateScenarioState validates the current state of a scenario of the mock server.
+func ValidateScenarioState(ctx context.Context, server, scenario, expected string) error {
+	states, err := GetScenarioStates(ctx, server)
+	if err != nil {
+		return err
+	}
+	state, found := states[scenario]
+	if !found {
+		state = StateStarted
+	}
+	if state != expected {
+		return fmt.Errorf("mismatch of state of the mock scenario '%s': expected '%s', actual '%s'",
+			scenario, expected, state)
+	}
+	return nil
+}
+
+// ResetScenarios moves all the scenarios of the mock server to the initial state.
+func ResetScenarios(ctx context.Context, server string) error {
+	resp, err := sendMockCommand(ctx, http.MethodDelete, server, "/_mock/scenarios", nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// ResetMockServer removes the mockRequests (except the ones of the mapping files),
+// resets the scenarios and cleans the journal of the mock server.
+func ResetMockServer(ctx context.Context, server string) error {
+	resp, err := sendMockCommand(ctx, http.MethodPost, server, "/_mock/reset", nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
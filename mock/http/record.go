@@ -0,0 +1,294 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Redacted is the value that replaces the redacted headers and JSON properties.
+const Redacted = "REDACTED"
+
+// RecordOptions configures the recording proxy.
+type RecordOptions struct {
+	// Upstream is the URL of the real server (e.g. https://api.example.com).
+	Upstream string
+	// Dir is the directory where the mapping files are saved.
+	Dir string
+	// RedactHeaders are the request and response headers whose values are redacted.
+	RedactHeaders []string
+	// RedactJSON are the properties (gjson paths) of the request and response JSON bodies
+	// whose values are redacted.
+	RedactJSON []string
+	// Match configures which parts of the request must match in playback.
+	Match MatchOptions
+}
+
+// MatchOptions configures which parts of a recorded request must match in playback.
+// The method and path always match.
+type MatchOptions struct {
+	// Query is true to match the query params.
+	Query bool
+	// Headers are the request headers to match. The redacted headers are not matched.
+	Headers []string
+	// Body is true to match the request body. The properties of JSON bodies are matched,
+	// except the redacted ones, and other bodies are matched literally.
+	Body bool
+}
+
+// Recorder is a reverse proxy to an upstream server that saves the requests and responses
+// as mapping files. Repeated requests are saved as a sequence of responses in the same file.
+type Recorder struct {
+	options  RecordOptions
+	proxy    *httputil.ReverseProxy
+	mappings map[string]*recordedMapping
+	mutex    sync.Mutex
+}
+
+type recordedMapping struct {
+	file        string
+	mockRequest *MockRequest
+}
+
+// recordedRequestKey is the context key of the inbound request.
+type recordedRequestKey struct{}
+
+// recordedRequest is the inbound request, before it is rewritten to the upstream
+// (e.g. with the path of the upstream URL).
+type recordedRequest struct {
+	method string
+	url    *url.URL
+	header http.Header
+	body   []byte
+}
+
+// NewRecorder creates a recording proxy.
+func NewRecorder(options RecordOptions) (*Recorder, error) {
+	upstream, err := url.Parse(options.Upstream)
+	if err != nil || upstream.Scheme == "" || upstream.Host == "" {
+		return nil, fmt.Errorf("invalid upstream '%s'", options.Upstream)
+	}
+	if options.Dir == "" {
+		return nil, errors.New("missing directory of the mapping files")
+	}
+	if err := os.MkdirAll(options.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed creating mappings directory '%s': %w", options.Dir, err)
+	}
+	rec := &Recorder{options: options, mappings: map[string]*recordedMapping{}}
+	rec.proxy = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(upstream)
+			// the bodies are saved uncompressed
+			r.Out.Header.Del("Accept-Encoding")
+		},
+		ModifyResponse: rec.record,
+	}
+	return rec, nil
+}
+
+// ServeHTTP proxies the request to the upstream and saves the mapping.
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	inbound := recordedRequest{
+		method: r.Method,
+		url:    r.URL,
+		header: r.Header.Clone(),
+		body:   readBody(r),
+	}
+	ctx := context.WithValue(r.Context(), recordedRequestKey{}, inbound)
+	rec.proxy.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func (rec *Recorder) record(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed reading the upstream response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	inbound, ok := resp.Request.Context().Value(recordedRequestKey{}).(recordedRequest)
+	if !ok {
+		return errors.New("missing the inbound request of the upstream response")
+	}
+	request := rec.request(inbound)
+	response := Response{
+		Status:  resp.StatusCode,
+		Headers: rec.responseHeaders(resp.Header),
+		Body:    string(rec.redactJSON(body)),
+	}
+	return rec.save(request, response)
+}
+
+// request builds the filter of the recorded request according to the match options.
+// The filter matches the inbound request received by the mock server, not the request
+// sent to the upstream.
+func (rec *Recorder) request(r recordedRequest) Request {
+	body := r.body
+	request := Request{Method: r.method, Path: r.url.Path}
+	if rec.options.Match.Query && len(r.url.Query()) > 0 {
+		request.Query = r.url.Query()
+	}
+	for _, header := range rec.options.Match.Headers {
+		values := r.header.Values(header)
+		if len(values) == 0 || rec.redactedHeader(header) {
+			continue
+		}
+		if request.Headers == nil {
+			request.Headers = map[string][]string{}
+		}
+		request.Headers[http.CanonicalHeaderKey(header)] = values
+	}
+	if rec.options.Match.Body && len(body) > 0 {
+		if parsed := gjson.ParseBytes(body); parsed.IsObject() && json.Valid(body) {
+			request.JSON = rec.jsonProperties(body)
+		} else {
+			request.BodyRegex = "^" + regexp.QuoteMeta(string(body)) + "$"
+		}
+	}
+	return request
+}
+
+// jsonProperties returns the top-level properties of a JSON object without the redacted
+// properties.
+func (rec *Recorder) jsonProperties(body []byte) map[string]interface{} {
+	for _, path := range rec.options.RedactJSON {
+		body, _ = sjson.DeleteBytes(body, path)
+	}
+	props := map[string]interface{}{}
+	gjson.ParseBytes(body).ForEach(func(key, value gjson.Result) bool {
+		props[escapeJSONPath(key.String())] = value.Value()
+		return true
+	})
+	return props
+}
+
+// escapeJSONPath escapes the gjson special characters of a property name.
+func escapeJSONPath(key string) string {
+	var b strings.Builder
+	for _, c := range key {
+		if strings.ContainsRune(`.*?|#@\`, c) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func (rec *Recorder) responseHeaders(header http.Header) map[string][]string {
+	headers := map[string][]string{}
+	for name, values := range header {
+		// the mock server calculates these headers
+		if name == "Content-Length" || name == "Date" || name == "Transfer-Encoding" {
+			continue
+		}
+		if rec.redactedHeader(name) {
+			values = []string{Redacted}
+		}
+		headers[name] = values
+	}
+	return headers
+}
+
+func (rec *Recorder) redactedHeader(header string) bool {
+	for _, redacted := range rec.options.RedactHeaders {
+		if strings.EqualFold(redacted, header) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactJSON redacts the properties of a JSON body. Other bodies are not modified.
+func (rec *Recorder) redactJSON(body []byte) []byte {
+	if len(rec.options.RedactJSON) == 0 || !json.Valid(body) {
+		return body
+	}
+	for _, path := range rec.options.RedactJSON {
+		if gjson.GetBytes(body, path).Exists() {
+			body, _ = sjson.SetBytes(body, path, Redacted)
+		}
+	}
+	return body
+}
+
+// save writes the mapping file of a recorded request, appending the response to the
+// sequence of responses if the request was already recorded.
+func (rec *Recorder) save(request Request, response Response) error {
+	key, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed encoding the recorded request: %w", err)
+	}
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	mapping, ok := rec.mappings[string(key)]
+	if ok {
+		mockRequest := mapping.mockRequest
+		if len(mockRequest.Responses) == 0 {
+			mockRequest.Responses = []Response{mockRequest.Response}
+			mockRequest.Response = Response{}
+		}
+		mockRequest.Responses = append(mockRequest.Responses, response)
+	} else {
+		mockRequest := &MockRequest{Permanent: true, Request: request, Response: response}
+		file, err := rec.newFile(request)
+		if err != nil {
+			return err
+		}
+		mapping = &recordedMapping{file: file, mockRequest: mockRequest}
+		rec.mappings[string(key)] = mapping
+	}
+	data, err := json.MarshalIndent(mapping.mockRequest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed encoding the mapping: %w", err)
+	}
+	if err := os.WriteFile(mapping.file, data, 0o600); err != nil {
+		return fmt.Errorf("failed writing mapping file '%s': %w", mapping.file, err)
+	}
+	return nil
+}
+
+var fileNameRegexp = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// newFile creates a new mapping file named after the method and path of the request.
+func (rec *Recorder) newFile(request Request) (string, error) {
+	name := strings.Trim(fileNameRegexp.ReplaceAllString(request.Path, "-"), "-")
+	name = strings.ToLower(request.Method) + "-" + name
+	for i := 1; ; i++ {
+		file := filepath.Join(rec.options.Dir, fmt.Sprintf("%s-%d.json", name, i))
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed creating mapping file '%s': %w", file, err)
+		}
+		return file, f.Close()
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRecorder(t *testing.T) {
+	tcs := []struct {
+		name    string
+		options RecordOptions
+	}{
+		{name: "missing upstream", options: RecordOptions{Dir: t.TempDir()}},
+		{name: "invalid upstream", options: RecordOptions{Upstream: "localhost", Dir: t.TempDir()}},
+		{name: "missing dir", options: RecordOptions{Upstream: "http://localhost"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewRecorder(tc.options)
+			require.Error(t, err)
+		})
+	}
+}
+
+func send(t *testing.T, method, target, body string, headers map[string]string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, target, strings.NewReader(body))
+	require.NoError(t, err)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(b)
+}
+
+func TestRecordAndPlayback(t *testing.T) {
+	var calls int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"call": %d, "token": "secret", "path": "%s", "request": %s}`,
+			n, r.URL.Path, body)
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	recording, server := newTestServer()
+	defer server.Close()
+	require.NoError(t, recording.Record(RecordOptions{
+		Upstream:      upstream.URL,
+		Dir:           dir,
+		RedactHeaders: []string{"set-cookie", "Authorization"},
+		RedactJSON:    []string{"token", "password", "request.password"},
+		Match: MatchOptions{
+			Query:   true,
+			Headers: []string{"X-Tenant", "Authorization"},
+			Body:    true,
+		},
+	}))
+	headers := map[string]string{"X-Tenant": "acme", "Authorization": "Bearer secret"}
+	userBody := `{"name": "alice", "password": "secret"}`
+	status, body := send(t, http.MethodPost, server.URL+"/users?debug=1", userBody, headers)
+	require.Equal(t, http.StatusCreated, status)
+	require.Contains(t, body, `"token": "secret"`)
+	send(t, http.MethodPost, server.URL+"/users?debug=1", userBody, headers)
+	send(t, http.MethodGet, server.URL+"/users/1", "", nil)
+	require.Len(t, recording.journal.Requests("", ""), 3)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	data, err := os.ReadFile(filepath.Join(dir, "post-users-1.json"))
+	require.NoError(t, err)
+	var recorded MockRequest
+	require.NoError(t, json.Unmarshal(data, &recorded))
+	require.Equal(t, Request{
+		Method:  http.MethodPost,
+		Path:    "/users",
+		Query:   map[string][]string{"debug": {"1"}},
+		Headers: map[string][]string{"X-Tenant": {"acme"}},
+		JSON:    map[string]interface{}{"name": "alice"},
+	}, recorded.Request)
+	require.Len(t, recorded.Responses, 2)
+	require.Equal(t, []string{Redacted}, recorded.Responses[0].Headers["Set-Cookie"])
+	require.Contains(t, recorded.Responses[0].Body, `"token": "REDACTED"`)
+	require.Contains(t, recorded.Responses[0].Body, `"password": "REDACTED"`)
+
+	playback, server := newTestServer()
+	defer server.Close()
+	require.NoError(t, playback.LoadMappings(dir))
+	upstream.Close()
+	status, body = send(t, http.MethodPost, server.URL+"/users?debug=1", userBody, headers)
+	require.Equal(t, http.StatusCreated, status)
+	require.Contains(t, body, `"call": 1`)
+	_, body = send(t, http.MethodPost, server.URL+"/users?debug=1", userBody, headers)
+	require.Contains(t, body, `"call": 2`)
+	status, _ = send(t, http.MethodPost, server.URL+"/users?debug=2", userBody, headers)
+	require.Equal(t, http.StatusNotFound, status)
+	status, body = send(t, http.MethodGet, server.URL+"/users/1", "", nil)
+	require.Equal(t, http.StatusCreated, status)
+	require.Contains(t, body, `"path": "/users/1"`)
+}
+
+func TestRecordUpstreamWithPath(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"path": "%s", "query": "%s"}`, r.URL.Path, r.URL.RawQuery)
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	recording, server := newTestServer()
+	defer server.Close()
+	require.NoError(t, recording.Record(RecordOptions{
+		Upstream: upstream.URL + "/v1?key=abc",
+		Dir:      dir,
+		Match:    MatchOptions{Query: true},
+	}))
+	status, body := send(t, http.MethodGet, server.URL+"/users?page=2", "", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, `"path": "/v1/users"`)
+
+	data, err := os.ReadFile(filepath.Join(dir, "get-users-1.json"))
+	require.NoError(t, err)
+	var recorded MockRequest
+	require.NoError(t, json.Unmarshal(data, &recorded))
+	require.Equal(t, Request{
+		Method: http.MethodGet,
+		Path:   "/users",
+		Query:  map[string][]string{"page": {"2"}},
+	}, recorded.Request)
+
+	playback, server := newTestServer()
+	defer server.Close()
+	require.NoError(t, playback.LoadMappings(dir))
+	status, body = send(t, http.MethodGet, server.URL+"/users?page=2", "", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, `"path": "/v1/users"`)
+}
@@ -15,7 +15,10 @@
 package http
 
 import (
+	"bytes"
+	"io"
 	"net/http"
+	"reflect"
 	"regexp"
 	"strings"
 	"sync"
@@ -25,7 +28,11 @@ import (
 
 type MockRequests struct {
 	mockRequests []*MockRequest
-	mutex        sync.Mutex
+	// mappings contains the mockRequests loaded from mapping files, restored on cleaning.
+	mappings []MockRequest
+	// states contains the current state of the scenarios (StateStarted if missing).
+	states map[string]string
+	mutex  sync.Mutex
 }
 
 // PushMockRequest adds a MockRequest to the list.
@@ -35,27 +42,152 @@ func (m *MockRequests) PushMockRequest(mockRequest *MockRequest) {
 	m.mockRequests = append(m.mockRequests, mockRequest)
 }
 
-// CleanMockRequests removes all the mockRequests from the list.
+// ReplaceMappings replaces the mockRequests loaded from mapping files.
+func (m *MockRequests) ReplaceMappings(mockRequests []*MockRequest) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.mappings = make([]MockRequest, 0, len(mockRequests))
+	for _, mockRequest := range mockRequests {
+		m.mappings = append(m.mappings, *mockRequest)
+	}
+	kept := make([]*MockRequest, 0, len(m.mockRequests)+len(mockRequests))
+	for _, mockRequest := range m.mockRequests {
+		if mockRequest.mapping == "" {
+			kept = append(kept, mockRequest)
+		}
+	}
+	m.mockRequests = append(kept, m.loadedMappings()...)
+}
+
+// loadedMappings returns new instances of the mockRequests loaded from mapping files.
+func (m *MockRequests) loadedMappings() []*MockRequest {
+	mockRequests := make([]*MockRequest, 0, len(m.mappings))
+	for _, mapping := range m.mappings {
+		mockRequest := mapping
+		mockRequests = append(mockRequests, &mockRequest)
+	}
+	return mockRequests
+}
+
+// MockRequests returns a copy of the active mockRequests.
+func (m *MockRequests) MockRequests() []MockRequest {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	mockRequests := make([]MockRequest, 0, len(m.mockRequests))
+	for _, mockRequest := range m.mockRequests {
+		mockRequests = append(mockRequests, *mockRequest)
+	}
+	return mockRequests
+}
+
+// CleanMockRequests removes all the mockRequests from the list and resets the scenarios.
+// The mockRequests loaded from mapping files are restored as they were loaded.
 func (m *MockRequests) CleanMockRequests() {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
-	m.mockRequests = nil
+	m.mockRequests = m.loadedMappings()
+	m.states = nil
 }
 
-// MatchMockRequest finds the first mockRequest matching the HTTP request.
+// MatchMockRequest finds the mockRequest matching the HTTP request with the highest priority.
+// Between mockRequests with the same priority, the first one pushed is selected.
 func (m *MockRequests) MatchMockRequest(r *http.Request) *MockRequest {
+	body := readBody(r)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.match(r, body)
+}
+
+func (m *MockRequests) match(r *http.Request, body []byte) *MockRequest {
+	var matched *MockRequest
+	for _, mockRequest := range m.mockRequests {
+		if matched != nil && mockRequest.Priority <= matched.Priority {
+			continue
+		}
+		if m.matchState(mockRequest) && matchMockRequest(r, body, mockRequest) {
+			matched = mockRequest
+		}
+	}
+	return matched
+}
+
+// ServeMockRequest finds the mockRequest matching the HTTP request and returns its next
+// response. It moves the scenario to the new state of the mockRequest, and removes the
+// mockRequest if it is not permanent and there are no more responses.
+// It returns nil if there is no mockRequest matching the request.
+func (m *MockRequests) ServeMockRequest(r *http.Request) (*MockRequest, Response) {
+	body := readBody(r)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	mockRequest := m.match(r, body)
+	if mockRequest == nil {
+		return nil, Response{}
+	}
+	resp := mockRequest.Response
+	remaining := 0
+	if n := len(mockRequest.Responses); n > 0 {
+		resp = mockRequest.Responses[min(mockRequest.served, n-1)]
+		remaining = n - mockRequest.served - 1
+	}
+	mockRequest.served++
+	if mockRequest.Scenario != "" && mockRequest.NewState != "" {
+		if m.states == nil {
+			m.states = make(map[string]string)
+		}
+		m.states[mockRequest.Scenario] = mockRequest.NewState
+	}
+	if !mockRequest.Permanent && remaining <= 0 {
+		m.remove(mockRequest)
+	}
+	return mockRequest, resp
+}
+
+// matchState returns true if the scenario of the mockRequest is in the required state.
+func (m *MockRequests) matchState(mockRequest *MockRequest) bool {
+	if mockRequest.Scenario == "" || mockRequest.RequiredState == "" {
+		return true
+	}
+	return m.scenarioState(mockRequest.Scenario) == mockRequest.RequiredState
+}
+
+func (m *MockRequests) scenarioState(scenario string) string {
+	if state, found := m.states[scenario]; found {
+		return state
+	}
+	return StateStarted
+}
+
+// ScenarioStates returns the current state of the scenarios of the mockRequests.
+func (m *MockRequests) ScenarioStates() map[string]string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	states := make(map[string]string)
 	for _, mockRequest := range m.mockRequests {
-		if matchMockRequest(r, mockRequest) {
-			return mockRequest
+		if mockRequest.Scenario != "" {
+			states[mockRequest.Scenario] = m.scenarioState(mockRequest.Scenario)
 		}
 	}
-	return nil
+	for scenario, state := range m.states {
+		states[scenario] = state
+	}
+	return states
+}
+
+// ResetScenarios moves all the scenarios to StateStarted.
+func (m *MockRequests) ResetScenarios() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.states = nil
 }
 
 // Remove a mockRequest. It returns true if it was found and removed.
 func (m *MockRequests) RemoveMockRequest(mockRequest *MockRequest) bool {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
+	return m.remove(mockRequest)
+}
+
+func (m *MockRequests) remove(mockRequest *MockRequest) bool {
 	for i, mr := range m.mockRequests {
 		if mr != mockRequest {
 			continue
@@ -66,40 +198,124 @@ func (m *MockRequests) RemoveMockRequest(mockRequest *MockRequest) bool {
 	return false
 }
 
-func matchMockRequest(r *http.Request, mockRequest *MockRequest) bool {
+func matchMockRequest(r *http.Request, body []byte, mockRequest *MockRequest) bool {
 	mr := mockRequest.Request
 	if mr.Method != "" && r.Method != mr.Method {
 		return false
 	}
-	if !matchPath(r, mockRequest) {
+	if _, ok := PathParams(mr, r.URL.Path); !ok {
 		return false
 	}
-	if len(mr.Headers) != 0 {
-		for header, values := range mr.Headers {
-			rValues := r.Header[header]
-			for _, value := range values {
-				if !golium.ContainsString(value, rValues) {
-					return false
-				}
+	query := r.URL.Query()
+	queryValues := func(key string) []string { return query[key] }
+	if !matchValues(queryValues, mr.Query) || !matchValues(r.Header.Values, mr.Headers) {
+		return false
+	}
+	if !matchJSONProperties(body, mr.JSON) {
+		return false
+	}
+	if mr.BodyRegex != "" {
+		if matched, err := regexp.Match(mr.BodyRegex, body); err != nil || !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matchValues returns true if the actual values contain all the expected values of each key.
+func matchValues(actual func(key string) []string, expected map[string][]string) bool {
+	for key, values := range expected {
+		actualValues := actual(key)
+		for _, value := range values {
+			if !golium.ContainsString(value, actualValues) {
+				return false
 			}
 		}
 	}
 	return true
 }
 
-var defaultPattern = regexp.MustCompile(`<\*>`)
+var pathParamRegex = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
 
-func matchPath(r *http.Request, mockRequest *MockRequest) bool {
-	mr := mockRequest.Request
-	if mr.Path == "" {
+// PathParams matches the request path with the path or path regex of the filter.
+// It returns the path params of the path template or the named groups of the regex.
+func PathParams(mr Request, path string) (map[string]string, bool) {
+	params := map[string]string{}
+	if mr.Path == "" && mr.PathRegex == "" {
+		return nil, false
+	}
+	if mr.Path != "" {
+		re, err := pathRegexp(mr.Path)
+		if err != nil || !collectParams(re, path, params) {
+			return nil, false
+		}
+	}
+	if mr.PathRegex != "" {
+		re, err := regexp.Compile("^(?:" + mr.PathRegex + ")$")
+		if err != nil || !collectParams(re, path, params) {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// pathRegexp converts a path of a filter into a regular expression. The path params are
+// converted into named groups matching a path segment, and the suffix "<*>" matches any
+// path with the same prefix.
+func pathRegexp(path string) (*regexp.Regexp, error) {
+	prefix := strings.HasSuffix(path, "<*>")
+	path = strings.TrimSuffix(path, "<*>")
+	var expr strings.Builder
+	expr.WriteString("^")
+	last := 0
+	for _, loc := range pathParamRegex.FindAllStringSubmatchIndex(path, -1) {
+		expr.WriteString(regexp.QuoteMeta(path[last:loc[0]]))
+		expr.WriteString("(?P<" + path[loc[2]:loc[3]] + ">[^/]+)")
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(path[last:]))
+	if !prefix {
+		expr.WriteString("$")
+	}
+	return regexp.Compile(expr.String())
+}
+
+// collectParams matches the path with the regular expression and adds its named groups
+// to the params.
+func collectParams(re *regexp.Regexp, path string, params map[string]string) bool {
+	match := re.FindStringSubmatch(path)
+	if match == nil {
 		return false
 	}
-	corePath := strings.TrimSuffix(mr.Path, "<*>")
-	if defaultPattern.FindString(mr.Path) != "" && strings.HasPrefix(r.URL.Path, corePath) {
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			params[name] = match[i]
+		}
+	}
+	return true
+}
+
+// matchJSONProperties returns true if the JSON body has the properties.
+func matchJSONProperties(body []byte, props map[string]interface{}) bool {
+	if len(props) == 0 {
 		return true
 	}
-	if mr.Path != r.URL.Path {
-		return false
+	m := golium.NewMapFromJSONBytes(body)
+	for key, expectedValue := range props {
+		if !reflect.DeepEqual(m.Get(key), expectedValue) {
+			return false
+		}
 	}
 	return true
 }
+
+// readBody reads the request body and replaces it with a new reader with the same content.
+func readBody(r *http.Request) []byte {
+	if r.Body == nil {
+		return nil
+	}
+	body, _ := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body
+}
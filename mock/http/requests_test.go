@@ -0,0 +1,197 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchMockRequest(t *testing.T) {
+	tcs := []struct {
+		name     string
+		filter   Request
+		method   string
+		target   string
+		headers  map[string]string
+		body     string
+		expected bool
+	}{
+		{
+			name:     "exact path",
+			filter:   Request{Method: http.MethodGet, Path: "/users"},
+			target:   "/users",
+			expected: true,
+		},
+		{
+			name:   "method mismatch",
+			filter: Request{Method: http.MethodPost, Path: "/users"},
+			target: "/users",
+		},
+		{
+			name:     "path prefix",
+			filter:   Request{Path: "/users/<*>"},
+			target:   "/users/1/roles",
+			expected: true,
+		},
+		{
+			name:     "path template",
+			filter:   Request{Path: "/users/{id}/roles"},
+			target:   "/users/1/roles",
+			expected: true,
+		},
+		{
+			name:   "path template with several segments",
+			filter: Request{Path: "/users/{id}"},
+			target: "/users/1/roles",
+		},
+		{
+			name:     "path regex",
+			filter:   Request{PathRegex: "/users/[0-9]+"},
+			target:   "/users/12",
+			expected: true,
+		},
+		{
+			name:   "path regex mismatch",
+			filter: Request{PathRegex: "/users/[0-9]+"},
+			target: "/users/12/roles",
+		},
+		{
+			name:   "no path",
+			filter: Request{Method: http.MethodGet},
+			target: "/users",
+		},
+		{
+			name:     "query",
+			filter:   Request{Path: "/users", Query: map[string][]string{"tag": {"a", "b"}}},
+			target:   "/users?tag=b&tag=a&page=1",
+			expected: true,
+		},
+		{
+			name:   "query mismatch",
+			filter: Request{Path: "/users", Query: map[string][]string{"tag": {"a", "b"}}},
+			target: "/users?tag=a",
+		},
+		{
+			name:     "headers",
+			filter:   Request{Path: "/users", Headers: map[string][]string{"x-tenant": {"t1"}}},
+			target:   "/users",
+			headers:  map[string]string{"X-Tenant": "t1"},
+			expected: true,
+		},
+		{
+			name: "JSON body",
+			filter: Request{Path: "/users", JSON: map[string]interface{}{
+				"name": "alice", "age": float64(30), "roles.0": "admin",
+			}},
+			target:   "/users",
+			body:     `{"name": "alice", "age": 30, "roles": ["admin"]}`,
+			expected: true,
+		},
+		{
+			name:   "JSON body mismatch",
+			filter: Request{Path: "/users", JSON: map[string]interface{}{"name": "alice"}},
+			target: "/users",
+			body:   `{"name": "bob"}`,
+		},
+		{
+			name:     "body regex",
+			filter:   Request{Path: "/users", BodyRegex: `<name>al[a-z]+</name>`},
+			target:   "/users",
+			body:     `<user><name>alice</name></user>`,
+			expected: true,
+		},
+		{
+			name:   "body regex mismatch",
+			filter: Request{Path: "/users", BodyRegex: `^bob`},
+			target: "/users",
+			body:   `alice`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, tc.target, strings.NewReader(tc.body))
+			for name, value := range tc.headers {
+				r.Header.Set(name, value)
+			}
+			m := MockRequests{}
+			m.PushMockRequest(&MockRequest{Request: tc.filter})
+			require.Equal(t, tc.expected, m.MatchMockRequest(r) != nil)
+		})
+	}
+}
+
+func TestMatchMockRequestPriority(t *testing.T) {
+	generic := &MockRequest{Request: Request{Path: "/users/<*>"}}
+	specific := &MockRequest{Priority: 1, Request: Request{Path: "/users/{id}"}}
+	other := &MockRequest{Priority: 1, Request: Request{PathRegex: "/users/.*"}}
+	m := MockRequests{}
+	for _, mockRequest := range []*MockRequest{generic, specific, other} {
+		m.PushMockRequest(mockRequest)
+	}
+	require.Equal(t, specific,
+		m.MatchMockRequest(httptest.NewRequest(http.MethodGet, "/users/1", nil)))
+	require.Equal(t, other,
+		m.MatchMockRequest(httptest.NewRequest(http.MethodGet, "/users/1/roles", nil)))
+}
+
+func TestPathParams(t *testing.T) {
+	tcs := []struct {
+		name     string
+		filter   Request
+		path     string
+		expected map[string]string
+	}{
+		{
+			name: "no params", filter: Request{Path: "/users"}, path: "/users",
+			expected: map[string]string{},
+		},
+		{
+			name:     "template",
+			filter:   Request{Path: "/users/{id}/files/{file}.json"},
+			path:     "/users/1/files/report.json",
+			expected: map[string]string{"id": "1", "file": "report"},
+		},
+		{
+			name:     "regex",
+			filter:   Request{PathRegex: "/users/(?P<id>[0-9]+)"},
+			path:     "/users/12",
+			expected: map[string]string{"id": "12"},
+		},
+		{name: "no match", filter: Request{Path: "/users/{id}"}, path: "/groups/1"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			params, ok := PathParams(tc.filter, tc.path)
+			require.Equal(t, tc.expected != nil, ok)
+			require.Equal(t, tc.expected, params)
+		})
+	}
+}
+
+func TestRequestValidate(t *testing.T) {
+	require.NoError(t, Request{Path: "/users/{id}"}.Validate())
+	require.Error(t, Request{}.Validate())
+	require.Error(t, Request{PathRegex: "/users/("}.Validate())
+	require.Error(t, Request{Path: "/users", BodyRegex: "["}.Validate())
+}
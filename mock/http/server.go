@@ -15,38 +15,147 @@
 package http
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/http/httputil"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
 // Server type for the HTTP mock server.
+// Each server has its own mux, mockRequests and journal, so that several servers can run
+// in the same process.
 type Server struct {
-	Port         int
+	// Port of the server. If 0, a free port is selected when the server starts listening.
+	Port int
+	// TLS configures HTTPS. If nil, the server uses plain HTTP.
+	TLS          *TLSOptions
 	mockRequests MockRequests
+	journal      Journal
 	logger       *logrus.Entry
+	mux          *http.ServeMux
+	server       *http.Server
+	listener     net.Listener
+	recorder     *Recorder
+	upstream     *httputil.ReverseProxy
+	certPEM      []byte
+	mutex        sync.Mutex
 }
 
 // NewServer creates an instance of Server.
 func NewServer(port int) *Server {
-	return &Server{
+	s := &Server{
 		Port:         port,
 		mockRequests: MockRequests{},
+		journal:      Journal{},
 		logger:       logrus.WithField("mock", "http"),
+		mux:          http.NewServeMux(),
 	}
+	s.mux.HandleFunc("/_mock/requests", s.handleMockRequest)
+	s.mux.HandleFunc("/_mock/requests/received", s.handleReceivedRequests)
+	s.mux.HandleFunc("/_mock/scenarios", s.handleScenarios)
+	s.mux.HandleFunc("/_mock/reset", s.handleReset)
+	s.mux.HandleFunc("/", s.handle)
+	return s
+}
+
+// NewTLSServer creates an instance of Server with HTTPS.
+func NewTLSServer(port int, options *TLSOptions) *Server {
+	s := NewServer(port)
+	s.TLS = options
+	return s
+}
+
+// Handler returns the HTTP handler of the mock server.
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Listen opens the port of the server without serving the requests yet.
+// It updates the Port if it was 0. It is invoked by Start if required.
+func (s *Server) Listen() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.listener != nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.Port))
+	if err != nil {
+		return fmt.Errorf("failed listening at port %d: %w", s.Port, err)
+	}
+	s.server = &http.Server{Handler: s.mux, ReadHeaderTimeout: time.Minute}
+	if s.TLS != nil {
+		config, certPEM, err := s.TLS.config()
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		s.server.TLSConfig = config
+		s.certPEM = certPEM
+		listener = tls.NewListener(listener, config)
+	}
+	s.listener = listener
+	s.Port = listener.Addr().(*net.TCPAddr).Port
+	return nil
 }
 
 // Start the HTTP mock server.
-// Note that it blocks the current goroutine with http.ListenAndServe function.
+// Note that it blocks the current goroutine until the server is stopped.
 func (s *Server) Start() error {
-	http.HandleFunc("/_mock/requests", s.handleMockRequest)
-	http.HandleFunc("/", s.handle)
-	addr := fmt.Sprintf(":%d", s.Port)
-	s.logger.Infof("Starting server at '%s'", addr)
-	return http.ListenAndServe(addr, nil)
+	if err := s.Listen(); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	server, listener := s.server, s.listener
+	s.mutex.Unlock()
+	s.logger.Infof("Starting server at '%s'", s.URL())
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Stop the HTTP mock server gracefully, waiting for the active requests until the context
+// is done.
+func (s *Server) Stop(ctx context.Context) error {
+	s.mutex.Lock()
+	server := s.server
+	s.mutex.Unlock()
+	if server == nil {
+		return nil
+	}
+	s.logger.Infof("Stopping server at '%s'", s.URL())
+	return server.Shutdown(ctx)
+}
+
+// URL returns the URL of the server (e.g. https://localhost:9000).
+func (s *Server) URL() string {
+	scheme := "http"
+	if s.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://localhost:%d", scheme, s.Port)
+}
+
+// CertificatePEM returns the PEM of the server certificate (e.g. the generated one) to be
+// trusted by the clients. It is nil until the server is listening or without TLS.
+func (s *Server) CertificatePEM() []byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.certPEM
+}
+
+// SetJournalSize configures the maximum number of requests in the journal of received
+// requests (0 for DefaultJournalSize, negative to disable the journal).
+func (s *Server) SetJournalSize(size int) {
+	s.journal.SetMaxRequests(size)
 }
 
 func (s *Server) handleMockRequest(w http.ResponseWriter, r *http.Request) {
@@ -55,10 +164,21 @@ func (s *Server) handleMockRequest(w http.ResponseWriter, r *http.Request) {
 		var mockRequest MockRequest
 		if err := json.NewDecoder(r.Body).Decode(&mockRequest); err != nil {
 			s.logger.Errorf("Failed decoding mockRequest: %s", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := mockRequest.Validate(); err != nil {
+			s.logger.Errorf("Invalid mockRequest: %s", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 		s.logger.Infof("Pushing mockRequest: %s", mockRequest)
 		s.mockRequests.PushMockRequest(&mockRequest)
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s.mockRequests.MockRequests()); err != nil {
+			s.logger.Errorf("Failed encoding the mockRequests: %s", err)
+		}
 	case http.MethodDelete:
 		s.mockRequests.CleanMockRequests()
 	default:
@@ -66,19 +186,99 @@ func (s *Server) handleMockRequest(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleReceivedRequests returns the journal of received requests (GET), optionally
+// filtered by the query params method and path, or cleans the journal (DELETE).
+func (s *Server) handleReceivedRequests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		requests := s.journal.Requests(query.Get("method"), query.Get("path"))
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(requests); err != nil {
+			s.logger.Errorf("Failed encoding the received requests: %s", err)
+		}
+	case http.MethodDelete:
+		s.journal.Clean()
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleScenarios returns the current state of the scenarios (GET), or moves all the
+// scenarios to the initial state (DELETE).
+func (s *Server) handleScenarios(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s.mockRequests.ScenarioStates()); err != nil {
+			s.logger.Errorf("Failed encoding the scenario states: %s", err)
+		}
+	case http.MethodDelete:
+		s.mockRequests.ResetScenarios()
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleReset removes all the mockRequests (except the ones of the mapping files),
+// resets the scenarios and cleans the journal of received requests.
+func (s *Server) handleReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.mockRequests.CleanMockRequests()
+	s.journal.Clean()
+}
+
+// Record configures the server as a recording proxy. All the requests, except the ones
+// to the mock API, are proxied to the upstream and saved as mapping files to be played back
+// later (see LoadMappings).
+func (s *Server) Record(options RecordOptions) error {
+	recorder, err := NewRecorder(options)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.recorder = recorder
+	return nil
+}
+
 func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
-	mockRequest := s.mockRequests.MatchMockRequest(r)
+	s.mutex.Lock()
+	recorder := s.recorder
+	s.mutex.Unlock()
+	if recorder != nil {
+		s.journal.Record(r, true)
+		recorder.ServeHTTP(w, r)
+		return
+	}
+	mockRequest, resp := s.mockRequests.ServeMockRequest(r)
+	s.journal.Record(r, mockRequest != nil)
 	if mockRequest == nil {
-		w.WriteHeader(http.StatusNotFound)
+		s.proxy(w, r, nil)
+		return
+	}
+	if !delay(r, mockRequest.Latency, mockRequest.Jitter) {
 		return
 	}
-	if !mockRequest.Permanent {
-		s.mockRequests.RemoveMockRequest(mockRequest)
+	if mockRequest.Fault.applies() {
+		if err := mockRequest.Fault.inject(w); err != nil {
+			s.logger.Errorf("Failed injecting fault '%s': %s", mockRequest.Fault.Type, err)
+		}
+		return
 	}
-	if mockRequest.Latency > 0 {
-		time.Sleep(time.Duration(mockRequest.Latency) * time.Millisecond)
+	if mockRequest.Proxy != nil {
+		s.proxy(w, r, mockRequest.Proxy)
+		return
+	}
+	resp, err := renderResponse(mockRequest, resp, r)
+	if err != nil {
+		s.logger.Errorf("Failed rendering the response template: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-	resp := mockRequest.Response
 	if http.StatusText(resp.Status) == "" {
 		s.logger.Errorf("Status code to return not valid: %d", resp.Status)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -90,7 +290,12 @@ func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	w.WriteHeader(resp.Status)
-	if _, err := w.Write([]byte(resp.Body)); err != nil {
+	if mockRequest.BytesPerSecond > 0 {
+		err = trickle(w, r, []byte(resp.Body), mockRequest.BytesPerSecond)
+	} else {
+		_, err = w.Write([]byte(resp.Body))
+	}
+	if err != nil {
 		s.logger.Errorf("Failed writing the response body: %s", err)
 	}
 }
@@ -0,0 +1,269 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/stretchr/testify/require"
+)
+
+// initTestEnvironment loads the environment configuration from testdata because
+// the mock client reads the proxy configuration.
+func initTestEnvironment() {
+	golium.GetConfig().Dir.Environments = "testdata/environments"
+}
+
+// newTestServer starts a mock server with its own mux.
+func newTestServer() (*Server, *httptest.Server) {
+	initTestEnvironment()
+	s := NewServer(0)
+	return s, httptest.NewServer(s.Handler())
+}
+
+func TestJournalRequests(t *testing.T) {
+	var journal Journal
+	for _, target := range []string{"/notify", "/notify/1", "/users"} {
+		r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"id": 1}`))
+		journal.Record(r, true)
+	}
+	journal.Record(httptest.NewRequest(http.MethodGet, "/users?name=alice", nil), false)
+
+	tcs := []struct {
+		name     string
+		method   string
+		path     string
+		expected int
+	}{
+		{name: "all", expected: 4},
+		{name: "method", method: http.MethodPost, expected: 3},
+		{name: "path", path: "/users", expected: 2},
+		{name: "method and path", method: http.MethodGet, path: "/users", expected: 1},
+		{name: "path prefix", path: "/notify<*>", expected: 2},
+		{name: "no match", method: http.MethodDelete, expected: 0},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Len(t, journal.Requests(tc.method, tc.path), tc.expected)
+		})
+	}
+	users := journal.Requests(http.MethodGet, "/users")
+	require.Equal(t, map[string][]string{"name": {"alice"}}, users[0].Query)
+	require.False(t, users[0].Matched)
+	require.Equal(t, `{"id": 1}`, journal.Requests(http.MethodPost, "/users")[0].Body)
+
+	journal.Clean()
+	require.Empty(t, journal.Requests("", ""))
+}
+
+func TestJournalMaxRequests(t *testing.T) {
+	journal := Journal{MaxRequests: 2}
+	for _, path := range []string{"/a", "/b", "/c"} {
+		journal.Record(httptest.NewRequest(http.MethodGet, path, nil), true)
+	}
+	paths := func() []string {
+		var paths []string
+		for _, r := range journal.Requests("", "") {
+			paths = append(paths, r.Path)
+		}
+		return paths
+	}
+	require.Equal(t, []string{"/b", "/c"}, paths())
+
+	journal.SetMaxRequests(1)
+	require.Equal(t, []string{"/c"}, paths())
+
+	big := strings.Repeat("a", MaxJournalBodySize+1)
+	journal.Record(httptest.NewRequest(http.MethodPost, "/big", strings.NewReader(big)), true)
+	requests := journal.Requests(http.MethodPost, "/big")
+	require.Len(t, requests, 1)
+	require.True(t, requests[0].BodyTruncated)
+	require.Len(t, requests[0].Body, MaxJournalBodySize)
+
+	journal.SetMaxRequests(-1)
+	received := journal.Record(httptest.NewRequest(http.MethodGet, "/d", nil), true)
+	require.Equal(t, "/d", received.Path)
+	require.Empty(t, paths())
+}
+
+func TestValidateReceivedRequests(t *testing.T) {
+	ctx := context.Background()
+	_, server := newTestServer()
+	defer server.Close()
+	for _, body := range []string{`{"event": "paid", "amount": 10}`, `{"event": "refunded"}`} {
+		resp, err := http.Post(server.URL+"/notify", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+
+	tcs := []struct {
+		name    string
+		count   int
+		method  string
+		path    string
+		props   map[string]interface{}
+		wantErr bool
+	}{
+		{name: "count", count: 2, method: http.MethodPost, path: "/notify"},
+		{name: "any method and path", count: 2},
+		{
+			name: "JSON properties", count: 1, method: http.MethodPost, path: "/notify",
+			props: map[string]interface{}{"event": "paid", "amount": float64(10)},
+		},
+		{name: "wrong count", count: 1, method: http.MethodPost, path: "/notify", wantErr: true},
+		{name: "other path", count: 0, method: http.MethodPost, path: "/other"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateReceivedRequests(ctx, server.URL, tc.count, tc.method, tc.path, tc.props)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	require.NoError(t, ResetReceivedRequests(ctx, server.URL))
+	require.NoError(t, ValidateReceivedRequests(ctx, server.URL, 0, "", "", nil))
+}
+
+func TestResetMockServer(t *testing.T) {
+	ctx := context.Background()
+	s, server := newTestServer()
+	defer server.Close()
+	mockRequest := &MockRequest{
+		Permanent: true,
+		Request:   Request{Method: http.MethodGet, Path: "/users"},
+		Response:  Response{Status: http.StatusOK},
+	}
+	require.NoError(t, sendMockRequest(ctx, server.URL, mockRequest))
+	resp, err := http.Get(server.URL + "/users")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, s.journal.Requests("", ""), 1)
+
+	require.NoError(t, ResetMockServer(ctx, server.URL))
+	require.Empty(t, s.journal.Requests("", ""))
+	resp, err = http.Get(server.URL + "/users")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestMockResponseSequence(t *testing.T) {
+	unavailable := http.StatusServiceUnavailable
+	tcs := []struct {
+		name      string
+		permanent bool
+		expected  []int
+	}{
+		{
+			name:     "one-shot",
+			expected: []int{unavailable, unavailable, http.StatusOK, http.StatusNotFound},
+		},
+		{
+			name:      "permanent",
+			permanent: true,
+			expected:  []int{unavailable, unavailable, http.StatusOK, http.StatusOK},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			_, server := newTestServer()
+			defer server.Close()
+			mockRequest := &MockRequest{
+				Permanent: tc.permanent,
+				Request:   Request{Method: http.MethodGet, Path: "/retry"},
+				Responses: []Response{
+					{Status: unavailable},
+					{Status: unavailable},
+					{Status: http.StatusOK},
+				},
+			}
+			require.NoError(t, sendMockRequest(ctx, server.URL, mockRequest))
+			var statuses []int
+			for range tc.expected {
+				resp, err := http.Get(server.URL + "/retry")
+				require.NoError(t, err)
+				resp.Body.Close()
+				statuses = append(statuses, resp.StatusCode)
+			}
+			require.Equal(t, tc.expected, statuses)
+		})
+	}
+}
+
+func TestMockScenarioStates(t *testing.T) {
+	ctx := context.Background()
+	_, server := newTestServer()
+	defer server.Close()
+	mockRequests := []*MockRequest{
+		{
+			Permanent:     true,
+			Scenario:      "users",
+			RequiredState: StateStarted,
+			Request:       Request{Method: http.MethodGet, Path: "/users/1"},
+			Response:      Response{Status: http.StatusNotFound},
+		},
+		{
+			Permanent:     true,
+			Scenario:      "users",
+			RequiredState: StateStarted,
+			NewState:      "created",
+			Request:       Request{Method: http.MethodPost, Path: "/users"},
+			Response:      Response{Status: http.StatusCreated},
+		},
+		{
+			Permanent:     true,
+			Scenario:      "users",
+			RequiredState: "created",
+			Request:       Request{Method: http.MethodGet, Path: "/users/1"},
+			Response:      Response{Status: http.StatusOK},
+		},
+	}
+	for _, mockRequest := range mockRequests {
+		require.NoError(t, sendMockRequest(ctx, server.URL, mockRequest))
+	}
+	send := func(method, path string) int {
+		req, err := http.NewRequest(method, server.URL+path, http.NoBody)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.NoError(t, ValidateScenarioState(ctx, server.URL, "users", StateStarted))
+	require.Equal(t, http.StatusNotFound, send(http.MethodGet, "/users/1"))
+	require.Equal(t, http.StatusCreated, send(http.MethodPost, "/users"))
+	require.NoError(t, ValidateScenarioState(ctx, server.URL, "users", "created"))
+	require.Equal(t, http.StatusOK, send(http.MethodGet, "/users/1"))
+	require.Equal(t, http.StatusNotFound, send(http.MethodPost, "/users"))
+
+	require.NoError(t, ResetScenarios(ctx, server.URL))
+	require.NoError(t, ValidateScenarioState(ctx, server.URL, "users", StateStarted))
+	require.Equal(t, http.StatusNotFound, send(http.MethodGet, "/users/1"))
+
+	invalid := &MockRequest{NewState: "created", Request: Request{Path: "/users"}}
+	require.Error(t, sendMockRequest(ctx, server.URL, invalid))
+}
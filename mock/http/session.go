@@ -0,0 +1,173 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/TelefonicaTC2Tech/golium"
+)
+
+// StopTimeout is the maximum duration to wait for the active requests when the mock servers
+// of a scenario are stopped.
+const StopTimeout = 5 * time.Second
+
+// Session contains the mock servers started by a scenario.
+type Session struct {
+	servers map[string]*Server
+	// clientCertificates are the client certificates generated for the mock servers with
+	// mutual TLS.
+	clientCertificates map[string]tls.Certificate
+	mutex              sync.Mutex
+}
+
+// ServerOptions configures a mock server started by a scenario.
+type ServerOptions struct {
+	// Mappings is a directory with mapping files to load. It is optional.
+	Mappings string
+	// TLS is true to start an HTTPS server with a generated certificate.
+	TLS bool
+	// MutualTLS is true to start an HTTPS server requiring a client certificate.
+	// A client certificate is generated for the mock steps and the HTTP steps.
+	MutualTLS bool
+}
+
+// StartServer starts an in-process mock server on a free port and stores its URL in the
+// context with the key "mock.{name}.url".
+// An HTTPS server also stores the PEM of its certificate with the key "mock.{name}.certificate",
+// and with mutual TLS, the PEM of the client certificate and key with the keys
+// "mock.{name}.client-certificate" and "mock.{name}.client-key".
+func (s *Session) StartServer(ctx context.Context, name string, options ServerOptions) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.servers[name]; ok {
+		return fmt.Errorf("mock server '%s' is already running", name)
+	}
+	server := NewServer(0)
+	var clientCertPEM, clientKeyPEM []byte
+	if options.TLS || options.MutualTLS {
+		server.TLS = &TLSOptions{}
+	}
+	if options.MutualTLS {
+		var err error
+		if clientCertPEM, clientKeyPEM, err = generateCertificate(); err != nil {
+			return fmt.Errorf("failed generating client certificate: %w", err)
+		}
+		server.TLS.ClientCA = string(clientCertPEM)
+	}
+	if options.Mappings != "" {
+		if err := server.LoadMappings(options.Mappings); err != nil {
+			return err
+		}
+	}
+	if err := server.Listen(); err != nil {
+		return fmt.Errorf("failed starting mock server '%s': %w", name, err)
+	}
+	go func() {
+		if err := server.Start(); err != nil {
+			server.logger.Errorf("Failed serving mock server '%s': %s", name, err)
+		}
+	}()
+	if s.servers == nil {
+		s.servers = map[string]*Server{}
+		s.clientCertificates = map[string]tls.Certificate{}
+	}
+	s.servers[name] = server
+	put := func(key string, value interface{}) {
+		golium.GetContext(ctx).Put(fmt.Sprintf("mock.%s.%s", name, key), value)
+	}
+	put("url", server.URL())
+	if server.TLS != nil {
+		put("certificate", string(server.CertificatePEM()))
+	}
+	if options.MutualTLS {
+		cert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+		if err != nil {
+			return fmt.Errorf("invalid client certificate: %w", err)
+		}
+		s.clientCertificates[name] = cert
+		put("client-certificate", string(clientCertPEM))
+		put("client-key", string(clientKeyPEM))
+	}
+	return nil
+}
+
+// configureClientTLS adds the certificates of the HTTPS mock servers of the session
+// to the trusted CAs, and the generated client certificates, to a TLS configuration.
+func (s *Session) configureClientTLS(config *tls.Config) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for name, server := range s.servers {
+		if certPEM := server.CertificatePEM(); certPEM != nil {
+			config.RootCAs.AppendCertsFromPEM(certPEM)
+		}
+		if cert, ok := s.clientCertificates[name]; ok {
+			config.Certificates = append(config.Certificates, cert)
+		}
+	}
+}
+
+// Server returns a mock server started by the scenario, or nil if not found.
+func (s *Session) Server(name string) *Server {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.servers[name]
+}
+
+// SetUpstream configures the upstream of a mock server started by the scenario to proxy
+// the unmatched requests.
+func (s *Session) SetUpstream(name, upstream string) error {
+	server := s.Server(name)
+	if server == nil {
+		return fmt.Errorf("mock server '%s' is not running", name)
+	}
+	return server.SetUpstream(upstream)
+}
+
+// StopServer stops a mock server started by the scenario.
+func (s *Session) StopServer(ctx context.Context, name string) error {
+	s.mutex.Lock()
+	server, ok := s.servers[name]
+	delete(s.servers, name)
+	delete(s.clientCertificates, name)
+	s.mutex.Unlock()
+	if !ok {
+		return fmt.Errorf("mock server '%s' is not running", name)
+	}
+	if err := server.Stop(ctx); err != nil {
+		return fmt.Errorf("failed stopping mock server '%s': %w", name, err)
+	}
+	return nil
+}
+
+// StopServers stops all the mock servers started by the scenario.
+func (s *Session) StopServers(ctx context.Context) error {
+	s.mutex.Lock()
+	names := make([]string, 0, len(s.servers))
+	for name := range s.servers {
+		names = append(names, name)
+	}
+	s.mutex.Unlock()
+	var errs []error
+	for _, name := range names {
+		errs = append(errs, s.StopServer(ctx, name))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionServers(t *testing.T) {
+	ctx := golium.InitializeContext(context.Background())
+	var session Session
+	require.NoError(t, session.StartServer(ctx, "payments", ServerOptions{}))
+	require.Error(t, session.StartServer(ctx, "payments", ServerOptions{}))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "orders.yaml"),
+		[]byte("request:\n  path: /orders\nresponse:\n  status: 202\n"), 0o600))
+	require.NoError(t, session.StartServer(ctx, "orders", ServerOptions{Mappings: dir}))
+	require.Error(t, session.StartServer(ctx, "invalid",
+		ServerOptions{Mappings: filepath.Join(dir, "missing")}))
+
+	paymentsURL := golium.GetContext(ctx).Get("mock.payments.url")
+	ordersURL := golium.GetContext(ctx).Get("mock.orders.url")
+	require.Equal(t, session.Server("payments").URL(), paymentsURL)
+	require.NotEqual(t, paymentsURL, ordersURL)
+	require.Nil(t, session.Server("invalid"))
+
+	resp, err := http.Get(ordersURL.(string) + "/orders")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	require.NoError(t, session.StopServer(ctx, "orders"))
+	require.Error(t, session.StopServer(ctx, "orders"))
+	_, err = http.Get(ordersURL.(string) + "/orders")
+	require.Error(t, err)
+
+	require.NoError(t, session.StopServers(ctx))
+	require.Nil(t, session.Server("payments"))
+	_, err = http.Get(paymentsURL.(string) + "/")
+	require.Error(t, err)
+}
+
+func TestSessionTLSServers(t *testing.T) {
+	initTestEnvironment()
+	var session Session
+	ctx := context.WithValue(golium.InitializeContext(context.Background()), contextKey, &session)
+	require.NoError(t, session.StartServer(ctx, "secure", ServerOptions{TLS: true}))
+	require.NoError(t, session.StartServer(ctx, "mutual", ServerOptions{MutualTLS: true}))
+	defer session.StopServers(ctx)
+
+	c := golium.GetContext(ctx)
+	require.Contains(t, c.Get("mock.secure.certificate"), "BEGIN CERTIFICATE")
+	require.Nil(t, c.Get("mock.secure.client-certificate"))
+	require.Contains(t, c.Get("mock.mutual.client-key"), "PRIVATE KEY")
+
+	for _, name := range []string{"secure", "mutual"} {
+		server := c.Get("mock." + name + ".url").(string)
+		require.Contains(t, server, "https://")
+		mockRequest := &MockRequest{
+			Request:  Request{Method: http.MethodGet, Path: "/users"},
+			Response: Response{Status: http.StatusOK},
+		}
+		require.NoError(t, sendMockRequest(ctx, server, mockRequest))
+		require.NoError(t, ValidateReceivedRequests(ctx, server, 0, "", "", nil))
+		require.NoError(t, ResetMockServer(ctx, server))
+	}
+	// the mock client without the session does not trust the generated certificates
+	err := ResetMockServer(context.Background(), c.Get("mock.secure.url").(string))
+	require.ErrorContains(t, err, "certificate")
+}
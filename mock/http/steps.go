@@ -19,11 +19,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
-	"net/url"
-	"path"
 
 	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/har"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/proxy"
 	"github.com/cucumber/godog"
 )
 
@@ -33,6 +34,27 @@ type Steps struct {
 
 // InitializeSteps initializes all the steps.
 func (cs Steps) InitializeSteps(ctx context.Context, scenCtx *godog.ScenarioContext) context.Context {
+	// Initialize the mock HTTP session in the context
+	ctx = InitializeContext(ctx)
+	session := GetSession(ctx)
+	// Initialize the steps
+	scenCtx.Step(`^a mock (HTTP|HTTPS) server "([^"]*)" is running$`, func(scheme, name string) error {
+		options := ServerOptions{TLS: scheme == "HTTPS"}
+		return session.StartServer(ctx, golium.ValueAsString(ctx, name), options)
+	})
+	scenCtx.Step(`^a mock HTTPS server "([^"]*)" is running with mutual TLS$`, func(name string) error {
+		return session.StartServer(ctx, golium.ValueAsString(ctx, name), ServerOptions{MutualTLS: true})
+	})
+	scenCtx.Step(`^a mock (HTTP|HTTPS) server "([^"]*)" is running with the mappings "([^"]*)"$`, func(scheme, name, mappings string) error {
+		options := ServerOptions{TLS: scheme == "HTTPS", Mappings: golium.ValueAsString(ctx, mappings)}
+		return session.StartServer(ctx, golium.ValueAsString(ctx, name), options)
+	})
+	scenCtx.Step(`^the mock HTTP server "([^"]*)" proxies the unmatched requests to "([^"]*)"$`, func(name, upstream string) error {
+		return session.SetUpstream(golium.ValueAsString(ctx, name), golium.ValueAsString(ctx, upstream))
+	})
+	scenCtx.Step(`^I stop the mock HTTP server "([^"]*)"$`, func(name string) error {
+		return session.StopServer(ctx, golium.ValueAsString(ctx, name))
+	})
 	scenCtx.Step(`^I mock the HTTP request at "([^"]*)" for path "([^"]*)" with status "(\d+)" and JSON body$`, func(server, path string, status int, message *godog.DocString) error {
 		if http.StatusText(status) == "" {
 			return fmt.Errorf("status code to return not valid: %d", status)
@@ -44,7 +66,42 @@ func (cs Steps) InitializeSteps(ctx context.Context, scenCtx *godog.ScenarioCont
 		if err := json.Unmarshal([]byte(golium.ValueAsString(ctx, body.Content)), &mockRequest); err != nil {
 			return fmt.Errorf("failed unmarshalling to mockRequest: %w", err)
 		}
-		return sendMockRequest(golium.ValueAsString(ctx, server), &mockRequest)
+		return sendMockRequest(ctx, golium.ValueAsString(ctx, server), &mockRequest)
+	})
+	scenCtx.Step(`^the mock server at "([^"]*)" must have received "([^"]*)" "([^"]*)" requests? to "([^"]*)"$`, func(server, count, method, path string) error {
+		n, err := golium.ValueAsInt(ctx, count)
+		if err != nil {
+			return fmt.Errorf("invalid number of requests '%s': %w", count, err)
+		}
+		return ValidateReceivedRequests(ctx, golium.ValueAsString(ctx, server), n, golium.ValueAsString(ctx, method), golium.ValueAsString(ctx, path), nil)
+	})
+	scenCtx.Step(`^the mock server at "([^"]*)" must have received "([^"]*)" "([^"]*)" requests? to "([^"]*)" with JSON properties$`, func(server, count, method, path string, t *godog.Table) error {
+		n, err := golium.ValueAsInt(ctx, count)
+		if err != nil {
+			return fmt.Errorf("invalid number of requests '%s': %w", count, err)
+		}
+		props, err := golium.ConvertTableToMap(ctx, t)
+		if err != nil {
+			return fmt.Errorf("failed processing table to a map for the request body: %w", err)
+		}
+		return ValidateReceivedRequests(ctx, golium.ValueAsString(ctx, server), n, golium.ValueAsString(ctx, method), golium.ValueAsString(ctx, path), props)
+	})
+	scenCtx.Step(`^I reset the requests received by the mock server at "([^"]*)"$`, func(server string) error {
+		return ResetReceivedRequests(ctx, golium.ValueAsString(ctx, server))
+	})
+	scenCtx.Step(`^the mock server at "([^"]*)" must have the scenario "([^"]*)" in state "([^"]*)"$`, func(server, scenario, state string) error {
+		return ValidateScenarioState(ctx, golium.ValueAsString(ctx, server), golium.ValueAsString(ctx, scenario), golium.ValueAsString(ctx, state))
+	})
+	scenCtx.Step(`^I reset the scenarios of the mock server at "([^"]*)"$`, func(server string) error {
+		return ResetScenarios(ctx, golium.ValueAsString(ctx, server))
+	})
+	scenCtx.Step(`^I reset the mock server at "([^"]*)"$`, func(server string) error {
+		return ResetMockServer(ctx, golium.ValueAsString(ctx, server))
+	})
+	scenCtx.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		stopCtx, cancel := context.WithTimeout(context.Background(), StopTimeout)
+		defer cancel()
+		return ctx, session.StopServers(stopCtx)
 	})
 	return ctx
 }
@@ -66,26 +123,49 @@ func MockRequestSimple(ctx context.Context, server, httpPath string, status int,
 			Body: body,
 		},
 	}
-	return sendMockRequest(server, mockRequest)
+	return sendMockRequest(ctx, server, mockRequest)
 }
 
-func sendMockRequest(server string, mockRequest *MockRequest) error {
-	if mockRequest.Latency < 0 {
-		return fmt.Errorf("invalid latency: %d", mockRequest.Latency)
-	}
-	u, err := url.Parse(server)
+func sendMockRequest(ctx context.Context, server string, mockRequest *MockRequest) error {
+	u, err := mockURL(server, "/_mock/requests")
 	if err != nil {
-		return fmt.Errorf("invalid endpoint URL: %s: %w", server, err)
+		return err
 	}
-	u.Path = path.Join(u.Path, "/_mock/requests")
 	body, err := json.Marshal(mockRequest)
 	if err != nil {
 		return fmt.Errorf("failed marshalling mockRequest to json: %w", err)
 	}
-	resp, err := http.Post(u.String(), "application/json", bytes.NewBuffer(body))
+	client, err := newMockClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.CloseIdleConnections()
+	resp, err := client.Post(u, "application/json", bytes.NewBuffer(body))
 	if err != nil {
 		return fmt.Errorf("failed sending mockRequest to mock server: %w", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("mockRequest rejected by mock server with status code %d: %s",
+			resp.StatusCode, bytes.TrimSpace(msg))
+	}
 	return nil
 }
+
+// newMockClient creates the HTTP client to send commands to the mock server
+// with the proxy and TLS configuration of the environment.
+func newMockClient(ctx context.Context) (*http.Client, error) {
+	options, err := proxy.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed loading proxy configuration: %w", err)
+	}
+	tr, err := options.Transport()
+	if err != nil {
+		return nil, fmt.Errorf("failed configuring proxy: %w", err)
+	}
+	if tr.TLSClientConfig, err = clientTLSConfig(ctx); err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: har.Wrap(ctx, tr)}, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+)
+
+// templateTagRegex matches the tags of a response template. The tags are lowercase, unlike
+// golium tags, so that they are not evaluated by golium when the mockRequest is configured.
+var templateTagRegex = regexp.MustCompile(
+	`\[(path|query|header|json|body|method|uuid|now)(:[^\]]*)?\]`)
+
+// templateData contains the values of the incoming request available in a response template.
+type templateData struct {
+	request *http.Request
+	body    []byte
+	params  map[string]string
+}
+
+// renderTemplate replaces the tags of a response template:
+//
+//	[path:id]         path param of the path template or named group of the path regex
+//	[query:name]      first value of a query param
+//	[header:name]     first value of a request header
+//	[json:user.id]    property of the JSON request body (gjson path)
+//	[body]            request body
+//	[method]          request method
+//	[uuid]            random UUID
+//	[now]             current unix timestamp
+//	[now:{duration}:{format}] current time plus a duration, with a Go layout or unix
+//
+// A tag without value is replaced with an empty string.
+// It fails if a tag is invalid (e.g. a now tag without format).
+func renderTemplate(template string, data templateData) (string, error) {
+	var err error
+	rendered := templateTagRegex.ReplaceAllStringFunc(template, func(tag string) string {
+		match := templateTagRegex.FindStringSubmatch(tag)
+		name, arg := match[1], strings.TrimPrefix(match[2], ":")
+		switch name {
+		case "path":
+			return data.params[arg]
+		case "query":
+			return data.request.URL.Query().Get(arg)
+		case "header":
+			return data.request.Header.Get(arg)
+		case "json":
+			return gjson.GetBytes(data.body, arg).String()
+		case "body":
+			return string(data.body)
+		case "method":
+			return data.request.Method
+		case "uuid":
+			return uuid.New().String()
+		case "now":
+			now, nowErr := renderNow(match[2] != "", arg)
+			if nowErr != nil && err == nil {
+				err = fmt.Errorf("invalid tag '%s': %w", tag, nowErr)
+			}
+			return now
+		}
+		return tag
+	})
+	return rendered, err
+}
+
+// validateTemplate checks that the tags of a response template are valid.
+func validateTemplate(template string) error {
+	for _, match := range templateTagRegex.FindAllStringSubmatch(template, -1) {
+		if match[1] != "now" {
+			continue
+		}
+		if _, err := renderNow(match[2] != "", strings.TrimPrefix(match[2], ":")); err != nil {
+			return fmt.Errorf("invalid tag '%s': %w", match[0], err)
+		}
+	}
+	return nil
+}
+
+// renderNow formats the current time as the golium tags [NOW] and
+// [NOW:{duration}:{format}]. Without value, it returns the unix timestamp.
+func renderNow(valued bool, arg string) (string, error) {
+	now := time.Now()
+	if !valued {
+		return fmt.Sprint(now.Unix()), nil
+	}
+	parts := strings.SplitN(arg, ":", 2)
+	if len(parts) != 2 {
+		return "", errors.New("invalid now tag")
+	}
+	duration, format := parts[0], parts[1]
+	if duration != "" {
+		d, err := time.ParseDuration(duration)
+		if err != nil {
+			return "", fmt.Errorf("invalid duration in now tag: %w", err)
+		}
+		now = now.Add(d)
+	}
+	switch format {
+	case "unix":
+		return fmt.Sprint(now.Unix()), nil
+	default:
+		return now.Format(format), nil
+	}
+}
+
+// renderResponse renders the body and headers of a response of the mockRequest
+// if the response is a template.
+func renderResponse(mockRequest *MockRequest, resp Response, r *http.Request) (Response, error) {
+	if !resp.Template {
+		return resp, nil
+	}
+	params, _ := PathParams(mockRequest.Request, r.URL.Path)
+	data := templateData{request: r, body: readBody(r), params: params}
+	body, err := renderTemplate(resp.Body, data)
+	if err != nil {
+		return resp, fmt.Errorf("failed rendering the response body: %w", err)
+	}
+	rendered := Response{
+		Status:   resp.Status,
+		Template: resp.Template,
+		Headers:  make(map[string][]string, len(resp.Headers)),
+		Body:     body,
+	}
+	for header, values := range resp.Headers {
+		for _, value := range values {
+			value, err := renderTemplate(value, data)
+			if err != nil {
+				return resp, fmt.Errorf("failed rendering the response header '%s': %w", header, err)
+			}
+			rendered.Headers[header] = append(rendered.Headers[header], value)
+		}
+	}
+	return rendered, nil
+}
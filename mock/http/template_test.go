@@ -0,0 +1,120 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/users/42?lang=es&lang=en",
+		strings.NewReader(`{"user": {"name": "alice", "roles": ["admin"]}}`))
+	r.Header.Set("X-Request-Id", "abc")
+	data := templateData{
+		request: r,
+		body:    readBody(r),
+		params:  map[string]string{"id": "42"},
+	}
+	tcs := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{name: "no tags", template: `{"values": [1, 2]}`, expected: `{"values": [1, 2]}`},
+		{name: "path param", template: `{"id": "[path:id]"}`, expected: `{"id": "42"}`},
+		{name: "query param", template: "[query:lang]", expected: "es"},
+		{name: "header", template: "[header:x-request-id]", expected: "abc"},
+		{name: "JSON property", template: "[json:user.name]", expected: "alice"},
+		{name: "JSON list", template: "[json:user.roles]", expected: `["admin"]`},
+		{name: "method", template: "[method] [path:missing]", expected: "POST "},
+		{
+			name:     "body",
+			template: "[body]",
+			expected: `{"user": {"name": "alice", "roles": ["admin"]}}`,
+		},
+		{name: "golium tags", template: "[CTXT:id]", expected: "[CTXT:id]"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			rendered, err := renderTemplate(tc.template, data)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, rendered)
+		})
+	}
+}
+
+func TestRenderTemplateGeneratedValues(t *testing.T) {
+	data := templateData{request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	render := func(template string) string {
+		rendered, err := renderTemplate(template, data)
+		require.NoError(t, err)
+		return rendered
+	}
+	_, err := uuid.Parse(render("[uuid]"))
+	require.NoError(t, err)
+	require.NotEqual(t, render("[uuid]"), render("[uuid]"))
+
+	unix, err := strconv.ParseInt(render("[now:1h:unix]"), 10, 64)
+	require.NoError(t, err)
+	require.InDelta(t, time.Now().Add(time.Hour).Unix(), unix, 5)
+	require.Equal(t, time.Now().Format("2006-01-02"), render("[now::2006-01-02]"))
+	unix, err = strconv.ParseInt(render("[now]"), 10, 64)
+	require.NoError(t, err)
+	require.InDelta(t, time.Now().Unix(), unix, 5)
+}
+
+func TestRenderTemplateInvalidNow(t *testing.T) {
+	data := templateData{request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	for _, template := range []string{"[now:]", "[now:unix]", "[now:1x:unix]"} {
+		t.Run(template, func(t *testing.T) {
+			_, err := renderTemplate(template, data)
+			require.Error(t, err)
+			require.Error(t, validateTemplate(template))
+			require.Error(t, Response{Body: template, Template: true}.Validate())
+			require.NoError(t, Response{Body: template}.Validate())
+		})
+	}
+	require.NoError(t, validateTemplate("[now] [now::unix] [now:-1h:2006-01-02]"))
+}
+
+func TestRenderResponse(t *testing.T) {
+	mockRequest := &MockRequest{
+		Request: Request{Path: "/users/{id}"},
+		Response: Response{
+			Status:  http.StatusOK,
+			Headers: map[string][]string{"Location": {"/users/[path:id]"}},
+			Body:    `{"id": "[path:id]"}`,
+		},
+	}
+	r := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	resp, err := renderResponse(mockRequest, mockRequest.Response, r)
+	require.NoError(t, err)
+	require.Equal(t, mockRequest.Response, resp)
+
+	mockRequest.Response.Template = true
+	resp, err = renderResponse(mockRequest, mockRequest.Response, r)
+	require.NoError(t, err)
+	require.Equal(t, `{"id": "1"}`, resp.Body)
+	require.Equal(t, []string{"/users/1"}, resp.Headers["Location"])
+	require.Equal(t, "/users/[path:id]", mockRequest.Response.Headers["Location"][0])
+}
@@ -0,0 +1,170 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/TelefonicaTC2Tech/golium"
+)
+
+// TLSOptions contains the TLS settings of the mock server.
+// The certificates and keys can be either a path to a PEM file or the PEM content.
+type TLSOptions struct {
+	// Certificate of the server. If empty, a self-signed certificate for localhost is generated.
+	Certificate string
+	// Key is the private key of the server certificate.
+	Key string
+	// ClientCA is a bundle of certificates to verify the client certificates (mutual TLS).
+	// If empty, the client certificates are not requested.
+	ClientCA string
+}
+
+// config builds the tls.Config of the server and returns the PEM of the server certificate.
+func (o *TLSOptions) config() (*tls.Config, []byte, error) {
+	var certPEM, keyPEM []byte
+	var err error
+	if o.Certificate == "" && o.Key == "" {
+		certPEM, keyPEM, err = generateCertificate()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed generating server certificate: %w", err)
+		}
+	} else {
+		if certPEM, err = golium.LoadPEM(o.Certificate); err != nil {
+			return nil, nil, fmt.Errorf("failed loading server certificate: %w", err)
+		}
+		if keyPEM, err = golium.LoadPEM(o.Key); err != nil {
+			return nil, nil, fmt.Errorf("failed loading server key: %w", err)
+		}
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid server certificate and key pair: %w", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if o.ClientCA != "" {
+		caPEM, err := golium.LoadPEM(o.ClientCA)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed loading client CA certificates: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, nil, errors.New("no valid client CA certificate found")
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, certPEM, nil
+}
+
+// clientTLSConfig builds the TLS configuration of the client of the mock server API.
+// It trusts the system CAs, the CA configured in the environment and the certificates of the
+// HTTPS mock servers started by the scenario. It presents the client certificate configured
+// in the environment and the client certificates generated for the mock servers of the
+// scenario with mutual TLS. The environment configuration is under the key "mockhttp.tls":
+//
+//	mockhttp:
+//	  tls:
+//	    ca: ./certs/mock-ca.pem
+//	    certificate: ./certs/client.pem
+//	    key: ./certs/client-key.pem
+func clientTLSConfig(ctx context.Context) (*tls.Config, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	config := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	if ca := golium.ValueAsStringOrEmpty(ctx, "[CONF:mockhttp.tls.ca]"); ca != "" {
+		caPEM, err := golium.LoadPEM(ca)
+		if err != nil {
+			return nil, fmt.Errorf("failed loading mock server CA certificates: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("no valid mock server CA certificate found")
+		}
+	}
+	cert := golium.ValueAsStringOrEmpty(ctx, "[CONF:mockhttp.tls.certificate]")
+	key := golium.ValueAsStringOrEmpty(ctx, "[CONF:mockhttp.tls.key]")
+	if cert != "" || key != "" {
+		certPEM, err := golium.LoadPEM(cert)
+		if err != nil {
+			return nil, fmt.Errorf("failed loading mock client certificate: %w", err)
+		}
+		keyPEM, err := golium.LoadPEM(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed loading mock client key: %w", err)
+		}
+		clientCert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mock client certificate and key pair: %w", err)
+		}
+		config.Certificates = append(config.Certificates, clientCert)
+	}
+	if session, ok := lookupSession(ctx); ok {
+		session.configureClientTLS(config)
+	}
+	return config, nil
+}
+
+// generateCertificate generates a self-signed certificate for localhost, valid for one year.
+func generateCertificate() (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "localhost", Organization: []string{"golium mock"}},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
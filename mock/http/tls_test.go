@@ -0,0 +1,130 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// startServer starts the server on a free port and stops it at the end of the test.
+func startServer(t *testing.T, s *Server) {
+	t.Helper()
+	require.NoError(t, s.Listen())
+	go s.Start()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, s.Stop(ctx))
+	})
+}
+
+func TestServersAreIndependent(t *testing.T) {
+	ctx := context.Background()
+	initTestEnvironment()
+	first, second := NewServer(0), NewServer(0)
+	startServer(t, first)
+	startServer(t, second)
+	require.NotEqual(t, first.Port, second.Port)
+
+	mockRequest := &MockRequest{
+		Request:  Request{Method: http.MethodGet, Path: "/users"},
+		Response: Response{Status: http.StatusOK},
+	}
+	require.NoError(t, sendMockRequest(ctx, first.URL(), mockRequest))
+	resp, err := http.Get(second.URL() + "/users")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, err = http.Get(first.URL() + "/users")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServerStop(t *testing.T) {
+	s := NewServer(0)
+	require.NoError(t, s.Listen())
+	done := make(chan error)
+	go func() { done <- s.Start() }()
+	resp, err := http.Get(s.URL() + "/users")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.NoError(t, s.Stop(context.Background()))
+	require.NoError(t, <-done)
+	_, err = http.Get(s.URL() + "/users")
+	require.Error(t, err)
+}
+
+func TestTLSServer(t *testing.T) {
+	clientCert, clientKey, err := generateCertificate()
+	require.NoError(t, err)
+	serverCert, serverKey, err := generateCertificate()
+	require.NoError(t, err)
+	tcs := []struct {
+		name       string
+		options    *TLSOptions
+		clientCert bool
+		wantErr    bool
+	}{
+		{name: "generated certificate", options: &TLSOptions{}},
+		{
+			name:    "provided certificate",
+			options: &TLSOptions{Certificate: string(serverCert), Key: string(serverKey)},
+		},
+		{
+			name:       "mutual TLS",
+			options:    &TLSOptions{ClientCA: string(clientCert)},
+			clientCert: true,
+		},
+		{
+			name:    "mutual TLS without client certificate",
+			options: &TLSOptions{ClientCA: string(clientCert)},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewTLSServer(0, tc.options)
+			startServer(t, s)
+			require.Contains(t, s.URL(), "https://localhost:")
+			pool := x509.NewCertPool()
+			require.True(t, pool.AppendCertsFromPEM(s.CertificatePEM()))
+			config := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+			if tc.clientCert {
+				cert, err := tls.X509KeyPair(clientCert, clientKey)
+				require.NoError(t, err)
+				config.Certificates = []tls.Certificate{cert}
+			}
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+			defer client.CloseIdleConnections()
+			resp, err := client.Get(s.URL() + "/users")
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		})
+	}
+}
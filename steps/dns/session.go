@@ -26,6 +26,8 @@ import (
 	"time"
 
 	"github.com/AdguardTeam/dnsproxy/upstream"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/har"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/proxy"
 	"github.com/google/uuid"
 	"github.com/miekg/dns"
 )
@@ -49,6 +51,9 @@ type Session struct {
 	RTT time.Duration
 	// Timeout is the maximum time to wait for a response. Expressed in Milliseconds
 	Timeout time.Duration
+	// Proxy configuration of the DoH client. If nil, the proxy is selected by the
+	// environment variables.
+	Proxy *proxy.Options
 }
 
 // ConfigureServer configures the DNS server location and the transport protocol.
@@ -72,6 +77,19 @@ func (s *Session) ConfigureDoHQueryParams(ctx context.Context, params map[string
 	s.DoHQueryParams = params
 }
 
+// ConfigureProxy configures the proxy of the DoH client (http, https, socks5 or socks5h URL).
+func (s *Session) ConfigureProxy(ctx context.Context, proxyURL string) error {
+	options := &proxy.Options{URL: proxyURL}
+	if s.Proxy != nil {
+		options.NoProxy = s.Proxy.NoProxy
+	}
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	s.Proxy = options
+	return nil
+}
+
 // SendUDPQuery sends a DNS query to resolve a domain.
 func (s *Session) SendUDPQuery(
 	ctx context.Context,
@@ -134,14 +152,17 @@ func (s *Session) SendDoHQuery(
 	if err != nil {
 		return err
 	}
-	// #nosec G402
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	tr, err := s.Proxy.Transport()
+	if err != nil {
+		return fmt.Errorf("failed configuring proxy: %w", err)
 	}
+	// #nosec G402
+	tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	client := &http.Client{
 		Timeout:   s.Timeout,
-		Transport: tr,
+		Transport: har.Wrap(ctx, tr),
 	}
+	defer client.CloseIdleConnections()
 	var request *http.Request
 	var urlStr string
 	var bodyRequest io.Reader
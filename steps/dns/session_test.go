@@ -2,9 +2,12 @@ package dns
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -137,6 +140,30 @@ func TestSendDoHQuery(t *testing.T) {
 	}
 }
 
+func TestSendDoHQueryWithProxy(t *testing.T) {
+	// The proxy answers the DoH queries without forwarding them
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := base64.RawURLEncoding.DecodeString(r.URL.Query().Get("dns"))
+		require.NoError(t, err)
+		query := &dns.Msg{}
+		require.NoError(t, query.Unpack(data))
+		reply := &dns.Msg{}
+		reply.SetReply(query)
+		body, err := reply.Pack()
+		require.NoError(t, err)
+		w.Header().Set(HeaderContentType, DoHMediaType)
+		w.Write(body)
+	}))
+	defer proxyServer.Close()
+	ctx, s := getContextAndSession()
+	createLogsDir()
+	s.ConfigureServer(ctx, "http://doh.golium.test/dns-query", "Doh with GET")
+	require.Error(t, s.ConfigureProxy(ctx, "doh.golium.test"))
+	require.NoError(t, s.ConfigureProxy(ctx, proxyServer.URL))
+	require.NoError(t, s.SendDoHQuery(ctx, "GET", dns.TypeA, "www.telefonica.net", true))
+	require.Equal(t, dns.RcodeSuccess, s.Response.Rcode)
+}
+
 func TestSendDoTQuery(t *testing.T) {
 	tcs := []struct {
 		name                   string
@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/proxy"
 	"github.com/cucumber/godog"
 	"github.com/miekg/dns"
 )
@@ -39,6 +40,14 @@ func (s Steps) InitializeSteps(ctx context.Context, scenCtx *godog.ScenarioConte
 	// Initialize the DNS session in the context
 	ctx = InitializeContext(ctx)
 	session := GetSession(ctx)
+	scenCtx.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
+		options, err := proxy.Load(ctx)
+		if err != nil {
+			return ctx, fmt.Errorf("failed loading proxy configuration: %w", err)
+		}
+		session.Proxy = options
+		return ctx, nil
+	})
 
 	// Initialize the steps
 	scenCtx.Step(`^the DNS server "([^"]*)"$`, func(svr string) {
@@ -64,6 +73,9 @@ func (s Steps) InitializeSteps(ctx context.Context, scenCtx *godog.ScenarioConte
 		session.ConfigureOptions(ctx, options)
 		return nil
 	})
+	scenCtx.Step(`^the DNS client uses the proxy "([^"]*)"$`, func(proxyURL string) error {
+		return session.ConfigureProxy(ctx, golium.ValueAsString(ctx, proxyURL))
+	})
 	scenCtx.Step(`^I send a DNS query of type "([^"]*)" for "([^"]*)"(\s\bwithout recursion\b)?$`, func(qtype, qname, recursion string) error {
 		recursive := recursion == ""
 		qtype = golium.ValueAsString(ctx, qtype)
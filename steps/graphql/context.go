@@ -0,0 +1,47 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/TelefonicaTC2Tech/golium/steps/http"
+)
+
+// ContextKey defines a type to store the GraphQL session in context.Context.
+type ContextKey string
+
+const contextKey ContextKey = "graphqlSession"
+
+// InitializeContext adds the GraphQL session to the context.
+// The GraphQL session reuses the HTTP session of the context, so that the HTTP steps configure
+// the transport of the GraphQL requests. It fails if the context has no HTTP session (e.g. the
+// HTTP steps are not initialized before).
+// The new context is returned because context is immutable.
+func InitializeContext(ctx context.Context) (context.Context, error) {
+	httpSession, ok := http.LookupSession(ctx)
+	if !ok {
+		return ctx, errors.New("missing HTTP session: the HTTP steps must be initialized " +
+			"before the GraphQL steps")
+	}
+	return context.WithValue(ctx, contextKey, &Session{HTTP: httpSession}), nil
+}
+
+// GetSession returns the GraphQL session stored in context.
+// Note that the context should be previously initialized with InitializeContext function.
+func GetSession(ctx context.Context) *Session {
+	return ctx.Value(contextKey).(*Session)
+}
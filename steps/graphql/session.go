@@ -0,0 +1,174 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	httpsteps "github.com/TelefonicaTC2Tech/golium/steps/http"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Session contains the information of a GraphQL request.
+// The request is sent with the HTTP session, reusing its endpoint, headers, timeout,
+// TLS, proxy and logging configuration.
+type Session struct {
+	// HTTP session to send the GraphQL requests.
+	HTTP *httpsteps.Session
+	// Query is the GraphQL document with the query or mutation.
+	Query string
+	// Variables of the GraphQL operation. The keys might use dot notation for nested objects.
+	Variables map[string]interface{}
+	// OperationName selects the operation when the document contains several operations.
+	OperationName string
+}
+
+// ConfigureEndpoint configures the endpoint of the GraphQL API.
+func (s *Session) ConfigureEndpoint(ctx context.Context, endpoint string) {
+	s.HTTP.ConfigureEndpoint(ctx, endpoint)
+}
+
+// ConfigureQuery configures the GraphQL document with a query or a mutation.
+func (s *Session) ConfigureQuery(ctx context.Context, query string) {
+	s.Query = query
+}
+
+// ConfigureVariables configures the variables of the GraphQL operation.
+func (s *Session) ConfigureVariables(ctx context.Context, variables map[string]interface{}) {
+	s.Variables = variables
+}
+
+// ConfigureOperationName configures the name of the GraphQL operation.
+func (s *Session) ConfigureOperationName(ctx context.Context, operationName string) {
+	s.OperationName = operationName
+}
+
+// requestBody builds the JSON body of the GraphQL request.
+func (s *Session) requestBody() (string, error) {
+	body, err := sjson.Set("", "query", s.Query)
+	if err != nil {
+		return "", fmt.Errorf("failed setting the GraphQL query: %w", err)
+	}
+	for key, value := range s.Variables {
+		if body, err = sjson.Set(body, "variables."+key, value); err != nil {
+			return "", fmt.Errorf("failed setting the GraphQL variable '%s': %w", key, err)
+		}
+	}
+	if s.OperationName != "" {
+		if body, err = sjson.Set(body, "operationName", s.OperationName); err != nil {
+			return "", fmt.Errorf("failed setting the GraphQL operation name: %w", err)
+		}
+	}
+	return body, nil
+}
+
+// SendRequest sends the GraphQL request as an HTTP POST request with a JSON body.
+func (s *Session) SendRequest(ctx context.Context) error {
+	if s.Query == "" {
+		return errors.New("no GraphQL query configured")
+	}
+	body, err := s.requestBody()
+	if err != nil {
+		return err
+	}
+	s.HTTP.ConfigureRequestBody(ctx, body)
+	return s.HTTP.SendHTTPRequest(ctx, http.MethodPost)
+}
+
+func (s *Session) responseBody() ([]byte, error) {
+	if s.HTTP.Response.HTTPResponse == nil {
+		return nil, errors.New("no GraphQL response")
+	}
+	body := s.HTTP.Response.ResponseBody
+	if !gjson.ValidBytes(body) {
+		return nil, fmt.Errorf("invalid GraphQL response: '%s'", body)
+	}
+	return body, nil
+}
+
+// ValidateData validates a list of properties in the data of the GraphQL response.
+// The properties are paths with dot notation relative to the data object.
+func (s *Session) ValidateData(ctx context.Context, props map[string]interface{}) error {
+	body, err := s.responseBody()
+	if err != nil {
+		return err
+	}
+	m := golium.NewMapFromJSONBytes(body)
+	for key, expectedValue := range props {
+		if value := m.Get("data." + key); value != expectedValue {
+			return fmt.Errorf("mismatch of GraphQL data property '%s': expected '%v', actual '%v'",
+				key, expectedValue, value)
+		}
+	}
+	return nil
+}
+
+// ValidateNoErrors validates that the GraphQL response does not contain errors.
+func (s *Session) ValidateNoErrors(ctx context.Context) error {
+	body, err := s.responseBody()
+	if err != nil {
+		return err
+	}
+	if errs := gjson.GetBytes(body, "errors"); len(errs.Array()) > 0 {
+		return fmt.Errorf("GraphQL response has errors: %s", errs.Raw)
+	}
+	return nil
+}
+
+// ValidateError validates that the GraphQL response contains an error with the properties
+// (e.g. message, path.0 or extensions.code).
+func (s *Session) ValidateError(ctx context.Context, props map[string]interface{}) error {
+	body, err := s.responseBody()
+	if err != nil {
+		return err
+	}
+	errs := gjson.GetBytes(body, "errors")
+	for _, entry := range errs.Array() {
+		m := golium.NewMapFromJSONBytes([]byte(entry.Raw))
+		if matchProperties(m, props) {
+			return nil
+		}
+	}
+	return fmt.Errorf("GraphQL response has no error with the properties %v: %s", props, errs.Raw)
+}
+
+func matchProperties(m golium.Map, props map[string]interface{}) bool {
+	for key, expectedValue := range props {
+		if m.Get(key) != expectedValue {
+			return false
+		}
+	}
+	return true
+}
+
+// StoreDataInContext extracts a property from the data of the GraphQL response and
+// stores it in the context.
+func (s *Session) StoreDataInContext(ctx context.Context, key, ctxtKey string) error {
+	body, err := s.responseBody()
+	if err != nil {
+		return err
+	}
+	value := golium.NewMapFromJSONBytes(body).Get("data." + key)
+	if value == nil {
+		return fmt.Errorf("GraphQL data property '%s' not found", key)
+	}
+	golium.GetContext(ctx).Put(ctxtKey, value)
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	httpsteps "github.com/TelefonicaTC2Tech/golium/steps/http"
+	"github.com/stretchr/testify/require"
+)
+
+const logsPath = "./logs"
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// newGraphQLServer answers with the user of the variables, or with an error if the
+// user is not found.
+func newGraphQLServer(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphqlRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("Content-Type", "application/json")
+		if req.Variables["id"] != "1" {
+			w.Write([]byte(`{"data": {"user": null}, "errors": [{"message": "user not found", ` +
+				`"path": ["user"], "extensions": {"code": "NOT_FOUND"}}]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"user": map[string]interface{}{
+					"id":        req.Variables["id"],
+					"operation": req.OperationName,
+					"filter":    req.Variables["filter"],
+					"query":     req.Query,
+				},
+			},
+		})
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestSendRequest(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := newGraphQLServer(t)
+	ctx, err := InitializeContext(httpsteps.InitializeContext(context.Background()))
+	require.NoError(t, err)
+	s := GetSession(ctx)
+	require.Same(t, httpsteps.GetSession(ctx), s.HTTP)
+	require.Error(t, s.SendRequest(ctx))
+	require.Error(t, s.ValidateNoErrors(ctx))
+
+	s.ConfigureEndpoint(ctx, ts.URL)
+	s.ConfigureQuery(ctx, "query GetUser($id: ID!) { user(id: $id) { id } }")
+	s.ConfigureVariables(ctx, map[string]interface{}{
+		"id":            "1",
+		"filter.active": true,
+		"filter.limit":  float64(10),
+	})
+	s.ConfigureOperationName(ctx, "GetUser")
+	require.NoError(t, s.SendRequest(ctx))
+	require.NoError(t, s.HTTP.ValidateStatusCode(ctx, http.StatusOK))
+	require.NoError(t, s.ValidateNoErrors(ctx))
+	require.NoError(t, s.ValidateData(ctx, map[string]interface{}{
+		"user.id":            "1",
+		"user.operation":     "GetUser",
+		"user.filter.active": true,
+		"user.filter.limit":  float64(10),
+		"user.query":         "query GetUser($id: ID!) { user(id: $id) { id } }",
+	}))
+	require.Error(t, s.ValidateData(ctx, map[string]interface{}{"user.id": "2"}))
+	require.Error(t, s.ValidateError(ctx, map[string]interface{}{"message": "user not found"}))
+
+	s.ConfigureVariables(ctx, map[string]interface{}{"id": "2"})
+	require.NoError(t, s.SendRequest(ctx))
+	require.Error(t, s.ValidateNoErrors(ctx))
+	require.NoError(t, s.ValidateData(ctx, map[string]interface{}{"user": nil}))
+	require.NoError(t, s.ValidateError(ctx, map[string]interface{}{
+		"message":         "user not found",
+		"path.0":          "user",
+		"extensions.code": "NOT_FOUND",
+	}))
+	require.Error(t, s.ValidateError(ctx, map[string]interface{}{
+		"extensions.code": "FORBIDDEN",
+	}))
+}
+
+func TestInitializeContextWithoutHTTPSession(t *testing.T) {
+	_, err := InitializeContext(context.Background())
+	require.Error(t, err)
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/cucumber/godog"
+)
+
+// Steps type is responsible to initialize the GraphQL steps in godog framework.
+// It must be initialized after the HTTP steps to share the HTTP session.
+type Steps struct {
+}
+
+// InitializeSteps adds GraphQL steps to the scenario context.
+// It implements StepsInitializer interface.
+// It returns a new context (context is immutable) with the GraphQL Context.
+func (s Steps) InitializeSteps(ctx context.Context, scenCtx *godog.ScenarioContext) context.Context {
+	// Initialize the GraphQL session in the context
+	ctx, err := InitializeContext(ctx)
+	if err != nil {
+		// Fail the scenarios instead of sending the GraphQL requests with a detached session
+		scenCtx.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
+			return ctx, err
+		})
+		return ctx
+	}
+	session := GetSession(ctx)
+	// Initialize the steps
+	scenCtx.Step(`^the GraphQL endpoint "([^"]*)"$`, func(endpoint string) {
+		session.ConfigureEndpoint(ctx, golium.ValueAsString(ctx, endpoint))
+	})
+	scenCtx.Step(`^the GraphQL (?:query|mutation)$`, func(message *godog.DocString) {
+		session.ConfigureQuery(ctx, golium.ValueAsString(ctx, message.Content))
+	})
+	scenCtx.Step(`^the GraphQL variables$`, func(t *godog.Table) error {
+		variables, err := golium.ConvertTableToMap(ctx, t)
+		if err != nil {
+			return fmt.Errorf("failed processing table to a map for the GraphQL variables: %w", err)
+		}
+		session.ConfigureVariables(ctx, variables)
+		return nil
+	})
+	scenCtx.Step(`^the GraphQL operation name "([^"]*)"$`, func(operationName string) {
+		session.ConfigureOperationName(ctx, golium.ValueAsString(ctx, operationName))
+	})
+	scenCtx.Step(`^I send the GraphQL request$`, func() error {
+		return session.SendRequest(ctx)
+	})
+	scenCtx.Step(`^the GraphQL response data must have the properties$`, func(t *godog.Table) error {
+		props, err := golium.ConvertTableToMap(ctx, t)
+		if err != nil {
+			return fmt.Errorf("failed processing table to a map for the GraphQL data: %w", err)
+		}
+		return session.ValidateData(ctx, props)
+	})
+	scenCtx.Step(`^the GraphQL response must not have errors$`, func() error {
+		return session.ValidateNoErrors(ctx)
+	})
+	scenCtx.Step(`^the GraphQL response must have an error with the properties$`, func(t *godog.Table) error {
+		props, err := golium.ConvertTableToMap(ctx, t)
+		if err != nil {
+			return fmt.Errorf("failed processing table to a map for the GraphQL error: %w", err)
+		}
+		return session.ValidateError(ctx, props)
+	})
+	scenCtx.Step(`^I store the GraphQL data "([^"]*)" in context "([^"]*)"$`, func(key, ctxtKey string) error {
+		return session.StoreDataInContext(ctx, golium.ValueAsString(ctx, key), golium.ValueAsString(ctx, ctxtKey))
+	})
+	return ctx
+}
@@ -0,0 +1,297 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/md5"  // #nosec G501
+	"crypto/sha1" // #nosec G505
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	bodyFilePattern = "golium-http-body-*"
+
+	SizeLessThan    = "less than"
+	SizeGreaterThan = "greater than"
+)
+
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// ConfigureMaxBodySize limits the size, in bytes, of the response body (0 for no limit).
+// The limit is applied to the decoded body.
+func (s *Session) ConfigureMaxBodySize(ctx context.Context, size int64) error {
+	if size < 0 {
+		return fmt.Errorf("invalid max body size '%d'", size)
+	}
+	s.MaxBodySize = size
+	return nil
+}
+
+// ConfigureBodyFile streams the response bodies to a temporary file instead of keeping
+// them in memory. The file is removed with the next response or at the end of the scenario.
+func (s *Session) ConfigureBodyFile(ctx context.Context) {
+	s.BodyToFile = true
+}
+
+// ConfigureAcceptEncoding sends the Accept-Encoding header with the encodings
+// (e.g. "gzip, br, deflate"). The response body is decoded by golium so that both
+// the compressed and the decompressed sizes are available.
+func (s *Session) ConfigureAcceptEncoding(ctx context.Context, encodings string) {
+	s.AcceptEncoding = encodings
+}
+
+func (s *Session) applyAcceptEncoding(req *http.Request) {
+	if s.AcceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", s.AcceptEncoding)
+	}
+}
+
+// readResponseBody reads the response body, decoding the content encoding
+// (if requested with ConfigureAcceptEncoding), applying the max body size and
+// storing it in memory or in a temporary file.
+func (s *Session) readResponseBody(resp *http.Response) error {
+	s.RemoveBodyFile()
+	s.Response.ResponseBody = nil
+	received := &countingReader{reader: resp.Body}
+	var body io.Reader = received
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	s.Response.ContentEncoding = encoding
+	if resp.Uncompressed {
+		// The body was transparently decompressed by the HTTP transport.
+		s.Response.ContentEncoding = "gzip"
+	} else if s.AcceptEncoding != "" && encoding != "" {
+		var err error
+		if body, err = newContentDecoder(encoding, body); err != nil {
+			return err
+		}
+	}
+	if s.MaxBodySize > 0 {
+		body = io.LimitReader(body, s.MaxBodySize+1)
+	}
+	var size int64
+	var err error
+	if s.BodyToFile {
+		size, err = s.writeBodyFile(body)
+	} else {
+		s.Response.ResponseBody, err = io.ReadAll(body)
+		size = int64(len(s.Response.ResponseBody))
+	}
+	if err != nil {
+		return fmt.Errorf("failed reading the response body: %w", err)
+	}
+	if s.MaxBodySize > 0 && size > s.MaxBodySize {
+		return fmt.Errorf("response body exceeds the max size of '%d' bytes", s.MaxBodySize)
+	}
+	s.Response.BodySize = size
+	s.Response.EncodedBodySize = received.count
+	if resp.Uncompressed {
+		s.Response.EncodedBodySize = -1
+	}
+	return nil
+}
+
+func (s *Session) writeBodyFile(body io.Reader) (int64, error) {
+	file, err := os.CreateTemp("", bodyFilePattern)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	s.Response.BodyFile = file.Name()
+	return io.Copy(file, body)
+}
+
+// RemoveBodyFile removes the temporary file with the last response body (if any).
+func (s *Session) RemoveBodyFile() {
+	if s.Response.BodyFile == "" {
+		return
+	}
+	if err := os.Remove(s.Response.BodyFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		GetLogger().Log.Infof("Failed removing response body file '%s': %s", s.Response.BodyFile, err)
+	}
+	s.Response.BodyFile = ""
+}
+
+// newContentDecoder returns a reader that decodes the content encodings.
+// The encodings are applied in the order they are listed, so they are decoded in reverse order.
+func newContentDecoder(contentEncoding string, body io.Reader) (io.Reader, error) {
+	encodings := strings.Split(contentEncoding, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var err error
+		switch encoding := strings.TrimSpace(encodings[i]); encoding {
+		case "gzip", "x-gzip":
+			body, err = gzip.NewReader(body)
+		case "br":
+			body = brotli.NewReader(body)
+		case "deflate":
+			body, err = newDeflateReader(body)
+		case "identity", "":
+		default:
+			return nil, fmt.Errorf("unsupported content encoding '%s'", encoding)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed decoding the '%s' response body: %w", encodings[i], err)
+		}
+	}
+	return body, nil
+}
+
+// newDeflateReader supports both zlib-wrapped (RFC 1950) and raw (RFC 1951) deflate bodies,
+// because both of them are used by servers with the "deflate" content encoding.
+func newDeflateReader(body io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(body)
+	header, err := buffered.Peek(2)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// responseBodyReader returns a reader of the response body, either from memory or from the file.
+func (s *Session) responseBodyReader() (io.ReadCloser, error) {
+	if s.Response.BodyFile != "" {
+		return os.Open(s.Response.BodyFile)
+	}
+	if s.Response.HTTPResponse == nil {
+		return nil, errors.New("no HTTP response")
+	}
+	return io.NopCloser(bytes.NewReader(s.Response.ResponseBody)), nil
+}
+
+// ValidateResponseBodyChecksum validates the checksum (md5, sha1, sha256 or sha512),
+// in hexadecimal, of the response body.
+func (s *Session) ValidateResponseBodyChecksum(ctx context.Context, algorithm, expected string,
+) error {
+	newHash, ok := checksumAlgorithms[strings.ToLower(algorithm)]
+	if !ok {
+		return fmt.Errorf("unsupported checksum algorithm '%s'", algorithm)
+	}
+	reader, err := s.responseBodyReader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	h := newHash()
+	if _, err := io.Copy(h, reader); err != nil {
+		return fmt.Errorf("failed calculating the checksum of the response body: %w", err)
+	}
+	checksum := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(checksum, expected) {
+		return fmt.Errorf("response body %s checksum mismatch: expected '%s', actual '%s'",
+			algorithm, expected, checksum)
+	}
+	return nil
+}
+
+// ValidateResponseBodySize validates the size of the response body. If encoded is true,
+// the size of the body as received (compressed) is validated; otherwise, the decoded size.
+// The comparison can be empty (equal), "less than" or "greater than".
+func (s *Session) ValidateResponseBodySize(ctx context.Context, encoded bool, comparison string,
+	expected int64,
+) error {
+	if s.Response.HTTPResponse == nil {
+		return errors.New("no HTTP response")
+	}
+	size, description := s.Response.BodySize, "response body size"
+	if encoded {
+		size, description = s.Response.EncodedBodySize, "response compressed body size"
+		if size < 0 {
+			return errors.New("unknown compressed body size: the body was decompressed " +
+				"by the HTTP transport, configure the accepted encodings")
+		}
+	}
+	var valid bool
+	switch comparison {
+	case "":
+		valid = size == expected
+	case SizeLessThan:
+		valid = size < expected
+	case SizeGreaterThan:
+		valid = size > expected
+	default:
+		return fmt.Errorf("invalid size comparison '%s'", comparison)
+	}
+	if !valid {
+		return fmt.Errorf("%s mismatch: expected %s, actual '%d' bytes",
+			description, strings.TrimSpace(fmt.Sprintf("%s '%d' bytes", comparison, expected)), size)
+	}
+	return nil
+}
+
+// ValidateResponseContentEncoding validates the content encoding of the response body.
+// The encoding "identity" (or empty) means that the body is not encoded.
+func (s *Session) ValidateResponseContentEncoding(ctx context.Context, expected string) error {
+	if s.Response.HTTPResponse == nil {
+		return errors.New("no HTTP response")
+	}
+	expected = strings.ToLower(expected)
+	if expected == "identity" {
+		expected = ""
+	}
+	actual := s.Response.ContentEncoding
+	if actual == "identity" {
+		actual = ""
+	}
+	if actual != expected {
+		return fmt.Errorf("response content encoding mismatch: expected '%s', actual '%s'",
+			expected, actual)
+	}
+	return nil
+}
+
+// StoreResponseBodyFileInContext stores the path of the file with the response body in the context.
+func (s *Session) StoreResponseBodyFileInContext(ctx context.Context, ctxtKey string) error {
+	if s.Response.BodyFile == "" {
+		return errors.New("the HTTP response body is not stored in a file")
+	}
+	golium.GetContext(ctx).Put(ctxtKey, s.Response.BodyFile)
+	return nil
+}
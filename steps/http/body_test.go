@@ -0,0 +1,224 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/require"
+)
+
+var encodedBody = strings.Repeat("golium ", 1000)
+
+func encodeBody(t *testing.T, encoding string) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		var err error
+		w, err = flate.NewWriter(&buf, flate.DefaultCompression)
+		require.NoError(t, err)
+	default:
+		return []byte(encodedBody)
+	}
+	_, err := w.Write([]byte(encodedBody))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+// newEncodingServer returns the body encoded with the encoding of the path (e.g. /gzip).
+func newEncodingServer(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := strings.TrimPrefix(r.URL.Path, "/")
+		body := encodeBody(t, encoding)
+		switch encoding {
+		case "raw-deflate":
+			w.Header().Set("Content-Encoding", "deflate")
+		case "gzip", "br", "deflate":
+			w.Header().Set("Content-Encoding", encoding)
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestResponseContentEncoding(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := newEncodingServer(t)
+	ctx := context.Background()
+	tests := []struct {
+		name             string
+		path             string
+		acceptEncoding   string
+		expectedEncoding string
+		compressedSize   int
+	}{
+		{
+			name:             "gzip",
+			path:             "/gzip",
+			acceptEncoding:   "gzip",
+			expectedEncoding: "gzip",
+			compressedSize:   len(encodeBody(t, "gzip")),
+		},
+		{
+			name:             "brotli",
+			path:             "/br",
+			acceptEncoding:   "br",
+			expectedEncoding: "br",
+			compressedSize:   len(encodeBody(t, "br")),
+		},
+		{
+			name:             "zlib deflate",
+			path:             "/deflate",
+			acceptEncoding:   "deflate",
+			expectedEncoding: "deflate",
+			compressedSize:   len(encodeBody(t, "deflate")),
+		},
+		{
+			name:             "raw deflate",
+			path:             "/raw-deflate",
+			acceptEncoding:   "deflate",
+			expectedEncoding: "deflate",
+			compressedSize:   len(encodeBody(t, "raw-deflate")),
+		},
+		{
+			name:             "identity",
+			path:             "/identity",
+			acceptEncoding:   "gzip, br, deflate",
+			expectedEncoding: "identity",
+			compressedSize:   len(encodedBody),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{}
+			s.ConfigureEndpoint(ctx, ts.URL)
+			s.ConfigurePath(tt.path)
+			s.ConfigureAcceptEncoding(ctx, tt.acceptEncoding)
+			require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+			require.Equal(t, encodedBody, string(s.Response.ResponseBody))
+			require.NoError(t, s.ValidateResponseContentEncoding(ctx, tt.expectedEncoding))
+			require.NoError(t, s.ValidateResponseBodySize(ctx, false, "", int64(len(encodedBody))))
+			require.NoError(t, s.ValidateResponseBodySize(ctx, true, "", int64(tt.compressedSize)))
+			if tt.expectedEncoding != "identity" {
+				require.NoError(t, s.ValidateResponseBodySize(ctx, true, SizeLessThan,
+					int64(len(encodedBody))))
+				require.Error(t, s.ValidateResponseContentEncoding(ctx, "identity"))
+			}
+		})
+	}
+}
+
+func TestResponseTransparentGzip(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := newEncodingServer(t)
+	ctx := context.Background()
+	s := &Session{}
+	s.ConfigureEndpoint(ctx, ts.URL)
+	s.ConfigurePath("/gzip")
+	require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+	require.Equal(t, encodedBody, string(s.Response.ResponseBody))
+	require.NoError(t, s.ValidateResponseContentEncoding(ctx, "gzip"))
+	require.Error(t, s.ValidateResponseBodySize(ctx, true, "", 0))
+}
+
+func TestResponseMaxBodySize(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := newEncodingServer(t)
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		maxBodySize int64
+		bodyToFile  bool
+		wantErr     bool
+	}{
+		{name: "no limit", maxBodySize: 0, wantErr: false},
+		{name: "below limit", maxBodySize: int64(len(encodedBody)), wantErr: false},
+		{name: "above limit", maxBodySize: 100, wantErr: true},
+		{name: "above limit in file", maxBodySize: 100, bodyToFile: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{BodyToFile: tt.bodyToFile}
+			defer s.RemoveBodyFile()
+			s.ConfigureEndpoint(ctx, ts.URL)
+			s.ConfigurePath("/gzip")
+			s.ConfigureAcceptEncoding(ctx, "gzip")
+			require.NoError(t, s.ConfigureMaxBodySize(ctx, tt.maxBodySize))
+			err := s.SendHTTPRequest(ctx, http.MethodGet)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Session.SendHTTPRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	require.Error(t, (&Session{}).ConfigureMaxBodySize(ctx, -1))
+}
+
+func TestResponseBodyFile(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := newEncodingServer(t)
+	ctx := context.Background()
+	sum := sha256.Sum256([]byte(encodedBody))
+	checksum := hex.EncodeToString(sum[:])
+
+	s := &Session{}
+	s.ConfigureEndpoint(ctx, ts.URL)
+	s.ConfigurePath("/br")
+	s.ConfigureAcceptEncoding(ctx, "br")
+	s.ConfigureBodyFile(ctx)
+	require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+	require.Empty(t, s.Response.ResponseBody)
+	bodyFile := s.Response.BodyFile
+	content, err := os.ReadFile(bodyFile)
+	require.NoError(t, err)
+	require.Equal(t, encodedBody, string(content))
+	require.NoError(t, s.ValidateResponseBodyChecksum(ctx, "sha256", checksum))
+	require.Error(t, s.ValidateResponseBodyChecksum(ctx, "md5", checksum))
+	require.Error(t, s.ValidateResponseBodyChecksum(ctx, "crc32", checksum))
+	require.NoError(t, s.ValidateResponseBodySize(ctx, false, SizeGreaterThan, 1000))
+	require.Error(t, s.ValidateResponseBodySize(ctx, false, SizeLessThan, 1000))
+
+	// The file of the previous response is removed with the next response
+	require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+	_, err = os.Stat(bodyFile)
+	require.True(t, os.IsNotExist(err))
+	s.RemoveBodyFile()
+	require.Empty(t, s.Response.BodyFile)
+}
@@ -34,3 +34,10 @@ func InitializeContext(ctx context.Context) context.Context {
 func GetSession(ctx context.Context) *Session {
 	return ctx.Value(contextKey).(*Session)
 }
+
+// LookupSession returns the HTTP session stored in context, and false if the context
+// was not initialized with InitializeContext function.
+func LookupSession(ctx context.Context) (*Session, bool) {
+	session, ok := ctx.Value(contextKey).(*Session)
+	return session, ok
+}
@@ -0,0 +1,219 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+var sameSiteModes = map[string]http.SameSite{
+	"":        http.SameSiteDefaultMode,
+	"default": http.SameSiteDefaultMode,
+	"lax":     http.SameSiteLaxMode,
+	"strict":  http.SameSiteStrictMode,
+	"none":    http.SameSiteNoneMode,
+}
+
+// Cookie is a row of a cookies table. The optional properties are nil when the column is
+// not included in the table, so that they are not considered in the validations.
+type Cookie struct {
+	Name     string
+	Value    *string
+	Path     *string
+	Domain   *string
+	Secure   *bool
+	HTTPOnly *bool
+	SameSite *string
+}
+
+// EnableCookieJar enables a cookie jar in the HTTP client. The cookies received in the
+// responses are stored in the jar and sent in the next requests of the session.
+func (s *Session) EnableCookieJar(ctx context.Context) error {
+	if s.CookieJar != nil {
+		return nil
+	}
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		return fmt.Errorf("failed creating the cookie jar: %w", err)
+	}
+	s.CookieJar = jar
+	return nil
+}
+
+// ClearCookies removes all the cookies from the cookie jar.
+func (s *Session) ClearCookies(ctx context.Context) error {
+	s.CookieJar = nil
+	return s.EnableCookieJar(ctx)
+}
+
+// ConfigureCookies stores the cookies in the cookie jar for the configured endpoint.
+// The cookie jar is enabled if it was not enabled yet.
+func (s *Session) ConfigureCookies(ctx context.Context, cookies []Cookie) error {
+	if err := s.EnableCookieJar(ctx); err != nil {
+		return err
+	}
+	u, err := s.cookieURL()
+	if err != nil {
+		return err
+	}
+	httpCookies := make([]*http.Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		cookie := &http.Cookie{Name: c.Name, Value: stringValue(c.Value)}
+		cookie.Path = stringValue(c.Path)
+		cookie.Domain = stringValue(c.Domain)
+		cookie.Secure = c.Secure != nil && *c.Secure
+		cookie.HttpOnly = c.HTTPOnly != nil && *c.HTTPOnly
+		if cookie.SameSite, err = parseSameSite(stringValue(c.SameSite)); err != nil {
+			return err
+		}
+		httpCookies = append(httpCookies, cookie)
+	}
+	s.CookieJar.SetCookies(u, httpCookies)
+	return nil
+}
+
+// ValidateCookie validates that the cookie jar contains a cookie, for the configured endpoint,
+// with the expected value.
+func (s *Session) ValidateCookie(ctx context.Context, name, expectedValue string) error {
+	cookie, err := s.findJarCookie(name)
+	if err != nil {
+		return err
+	}
+	if cookie == nil {
+		return fmt.Errorf("no cookie '%s' in the cookie jar", name)
+	}
+	if cookie.Value != expectedValue {
+		return fmt.Errorf("cookie '%s' mismatch: expected '%s', actual '%s'",
+			name, expectedValue, cookie.Value)
+	}
+	return nil
+}
+
+// ValidateNotCookie validates that the cookie jar does not contain a cookie
+// for the configured endpoint.
+func (s *Session) ValidateNotCookie(ctx context.Context, name string) error {
+	cookie, err := s.findJarCookie(name)
+	if err != nil {
+		return err
+	}
+	if cookie != nil {
+		return fmt.Errorf("cookie '%s' found in the cookie jar with value '%s'", name, cookie.Value)
+	}
+	return nil
+}
+
+// ValidateResponseCookies validates the cookies set by the HTTP response (Set-Cookie headers),
+// including their attributes (path, domain, secure, httponly and samesite).
+func (s *Session) ValidateResponseCookies(ctx context.Context, expectedCookies []Cookie) error {
+	if s.Response.HTTPResponse == nil {
+		return errors.New("no HTTP response")
+	}
+	cookies := make(map[string]*http.Cookie)
+	for _, cookie := range s.Response.HTTPResponse.Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	for _, expected := range expectedCookies {
+		cookie, ok := cookies[expected.Name]
+		if !ok {
+			return fmt.Errorf("HTTP response does not set the cookie '%s'", expected.Name)
+		}
+		if err := validateCookie(cookie, &expected); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateCookie(cookie *http.Cookie, expected *Cookie) error {
+	mismatch := func(attribute string, expectedValue, actualValue interface{}) error {
+		return fmt.Errorf("cookie '%s' %s mismatch: expected '%v', actual '%v'",
+			cookie.Name, attribute, expectedValue, actualValue)
+	}
+	if expected.Value != nil && *expected.Value != cookie.Value {
+		return mismatch("value", *expected.Value, cookie.Value)
+	}
+	if expected.Path != nil && *expected.Path != cookie.Path {
+		return mismatch("path", *expected.Path, cookie.Path)
+	}
+	if expected.Domain != nil && *expected.Domain != cookie.Domain {
+		return mismatch("domain", *expected.Domain, cookie.Domain)
+	}
+	if expected.Secure != nil && *expected.Secure != cookie.Secure {
+		return mismatch("secure", *expected.Secure, cookie.Secure)
+	}
+	if expected.HTTPOnly != nil && *expected.HTTPOnly != cookie.HttpOnly {
+		return mismatch("httponly", *expected.HTTPOnly, cookie.HttpOnly)
+	}
+	if expected.SameSite != nil {
+		sameSite, err := parseSameSite(*expected.SameSite)
+		if err != nil {
+			return err
+		}
+		if sameSite != cookie.SameSite {
+			return mismatch("samesite", *expected.SameSite, cookie.SameSite)
+		}
+	}
+	return nil
+}
+
+func (s *Session) findJarCookie(name string) (*http.Cookie, error) {
+	if s.CookieJar == nil {
+		return nil, errors.New("cookie jar is not enabled")
+	}
+	u, err := s.cookieURL()
+	if err != nil {
+		return nil, err
+	}
+	for _, cookie := range s.CookieJar.Cookies(u) {
+		if cookie.Name == name {
+			return cookie, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Session) cookieURL() (*url.URL, error) {
+	u, err := s.URL()
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, errors.New("the HTTP endpoint must be configured to manage cookies")
+	}
+	return u, nil
+}
+
+func parseSameSite(value string) (http.SameSite, error) {
+	sameSite, ok := sameSiteModes[strings.ToLower(value)]
+	if !ok {
+		return 0, fmt.Errorf("invalid samesite value '%s'", value)
+	}
+	return sameSite, nil
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
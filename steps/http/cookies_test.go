@@ -0,0 +1,239 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/stretchr/testify/require"
+)
+
+// newLoginServer creates a server that sets a session cookie and a CSRF token on login,
+// and only accepts requests to /account with both of them.
+func newLoginServer(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{
+				Name: "session", Value: "abc", Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode,
+			})
+			w.Header().Set("X-CSRF-Token", "header-token")
+			w.Write([]byte(`{"csrf": {"token": "body-token"}}`))
+		case "/account":
+			cookie, err := r.Cookie("session")
+			if err != nil || cookie.Value != "abc" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("X-Received-Token", r.Header.Get("X-CSRF-Token"))
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestCookieJar(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := newLoginServer(t)
+	ctx := context.Background()
+	tests := []struct {
+		name           string
+		cookieJar      bool
+		expectedStatus int
+	}{
+		{
+			name:           "without cookie jar",
+			cookieJar:      false,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "with cookie jar",
+			cookieJar:      true,
+			expectedStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{}
+			s.ConfigureEndpoint(ctx, ts.URL)
+			if tt.cookieJar {
+				require.NoError(t, s.EnableCookieJar(ctx))
+			}
+			s.ConfigurePath("/login")
+			require.NoError(t, s.SendHTTPRequest(ctx, http.MethodPost))
+			s.Request.Path = "/account"
+			require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+			require.NoError(t, s.ValidateStatusCode(ctx, tt.expectedStatus))
+		})
+	}
+}
+
+func TestCookieJarSetAndClear(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := newLoginServer(t)
+	ctx := context.Background()
+	s := &Session{}
+	require.Error(t, s.ValidateCookie(ctx, "session", "abc"))
+	s.ConfigureEndpoint(ctx, ts.URL)
+
+	value := "abc"
+	require.NoError(t, s.ConfigureCookies(ctx, []Cookie{{Name: "session", Value: &value}}))
+	require.NoError(t, s.ValidateCookie(ctx, "session", "abc"))
+	require.Error(t, s.ValidateCookie(ctx, "session", "other"))
+	s.ConfigurePath("/account")
+	require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+	require.NoError(t, s.ValidateStatusCode(ctx, http.StatusOK))
+
+	require.NoError(t, s.ClearCookies(ctx))
+	require.NoError(t, s.ValidateNotCookie(ctx, "session"))
+	require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+	require.NoError(t, s.ValidateStatusCode(ctx, http.StatusUnauthorized))
+}
+
+func TestValidateResponseCookies(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := newLoginServer(t)
+	ctx := context.Background()
+	s := &Session{}
+	s.ConfigureEndpoint(ctx, ts.URL)
+	s.ConfigurePath("/login")
+	require.NoError(t, s.SendHTTPRequest(ctx, http.MethodPost))
+
+	tests := []struct {
+		name    string
+		table   [][]string
+		wantErr bool
+	}{
+		{
+			name: "matching attributes",
+			table: [][]string{
+				{"name", "value", "path", "httponly", "secure", "samesite"},
+				{"session", "abc", "/", "true", "false", "Strict"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "only name",
+			table:   [][]string{{"name"}, {"session"}},
+			wantErr: false,
+		},
+		{
+			name:    "missing cookie",
+			table:   [][]string{{"name"}, {"other"}},
+			wantErr: true,
+		},
+		{
+			name:    "secure mismatch",
+			table:   [][]string{{"name", "secure"}, {"session", "true"}},
+			wantErr: true,
+		},
+		{
+			name:    "samesite mismatch",
+			table:   [][]string{{"name", "samesite"}, {"session", "Lax"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cookies []Cookie
+			require.NoError(t, golium.ConvertTableWithHeaderToStructSlice(
+				ctx, golium.NewTable(tt.table), &cookies))
+			err := s.ValidateResponseCookies(ctx, cookies)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Session.ValidateResponseCookies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigureCSRF(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := newLoginServer(t)
+	ctx := context.Background()
+	tests := []struct {
+		name          string
+		source        string
+		tokenName     string
+		expectedToken string
+		wantErr       bool
+	}{
+		{
+			name:          "from header",
+			source:        CSRFSourceHeader,
+			tokenName:     "X-CSRF-Token",
+			expectedToken: "header-token",
+		},
+		{
+			name:          "from body property",
+			source:        CSRFSourceBodyProperty,
+			tokenName:     "csrf.token",
+			expectedToken: "body-token",
+		},
+		{
+			name:          "from body pattern",
+			source:        CSRFSourceBodyPattern,
+			tokenName:     `"token": "([^"]+)"`,
+			expectedToken: "body-token",
+		},
+		{
+			name:          "from cookie",
+			source:        CSRFSourceCookie,
+			tokenName:     "session",
+			expectedToken: "abc",
+		},
+		{
+			name:      "pattern without group",
+			source:    CSRFSourceBodyPattern,
+			tokenName: `token`,
+			wantErr:   true,
+		},
+		{
+			name:    "invalid source",
+			source:  "query",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{}
+			s.ConfigureEndpoint(ctx, ts.URL)
+			require.NoError(t, s.EnableCookieJar(ctx))
+			err := s.ConfigureCSRF(ctx, tt.source, tt.tokenName, "X-CSRF-Token")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Session.ConfigureCSRF() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			s.ConfigurePath("/login")
+			require.NoError(t, s.SendHTTPRequest(ctx, http.MethodPost))
+			s.Request.Path = "/account"
+			require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+			require.NoError(t, s.ValidateResponseHeaders(ctx, map[string][]string{
+				"X-Received-Token": {tt.expectedToken},
+			}))
+		})
+	}
+}
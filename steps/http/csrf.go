@@ -0,0 +1,111 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/TelefonicaTC2Tech/golium"
+)
+
+// Sources of the CSRF token in the HTTP response.
+const (
+	CSRFSourceHeader       = "header"
+	CSRFSourceCookie       = "cookie"
+	CSRFSourceBodyProperty = "body property"
+	CSRFSourceBodyPattern  = "body pattern"
+)
+
+// CSRFOptions configures the extraction of a CSRF token from the HTTP responses
+// and its injection, as a header, in the next HTTP requests.
+type CSRFOptions struct {
+	// Source of the token in the response: header, cookie, body property (JSON path)
+	// or body pattern (regular expression with one capturing group).
+	Source string
+	// Name of the header, cookie, JSON property or the regular expression.
+	Name string
+	// Header of the request where the token is sent.
+	Header string
+	// Token is the last token extracted from a response.
+	Token string
+
+	pattern *regexp.Regexp
+}
+
+// ConfigureCSRF configures the CSRF token handling. If there is already a response in
+// the session, the token is extracted from it immediately.
+func (s *Session) ConfigureCSRF(ctx context.Context, source, name, header string) error {
+	options := &CSRFOptions{Source: source, Name: name, Header: header}
+	switch source {
+	case CSRFSourceHeader, CSRFSourceCookie, CSRFSourceBodyProperty:
+	case CSRFSourceBodyPattern:
+		pattern, err := regexp.Compile(name)
+		if err != nil {
+			return fmt.Errorf("invalid CSRF body pattern '%s': %w", name, err)
+		}
+		if pattern.NumSubexp() != 1 {
+			return fmt.Errorf("CSRF body pattern '%s' must have one capturing group", name)
+		}
+		options.pattern = pattern
+	default:
+		return fmt.Errorf("invalid CSRF token source '%s'", source)
+	}
+	s.CSRF = options
+	s.extractCSRFToken()
+	return nil
+}
+
+// extractCSRFToken updates the CSRF token if the response includes a new one.
+func (s *Session) extractCSRFToken() {
+	if s.CSRF == nil || s.Response.HTTPResponse == nil {
+		return
+	}
+	if token := s.CSRF.extract(s.Response.HTTPResponse, s.Response.ResponseBody); token != "" {
+		s.CSRF.Token = token
+	}
+}
+
+// applyCSRFToken adds the last CSRF token (if any) to the request.
+func (s *Session) applyCSRFToken(req *http.Request) {
+	if s.CSRF == nil || s.CSRF.Token == "" {
+		return
+	}
+	req.Header.Set(s.CSRF.Header, s.CSRF.Token)
+}
+
+func (o *CSRFOptions) extract(resp *http.Response, body []byte) string {
+	switch o.Source {
+	case CSRFSourceHeader:
+		return resp.Header.Get(o.Name)
+	case CSRFSourceCookie:
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == o.Name {
+				return cookie.Value
+			}
+		}
+	case CSRFSourceBodyProperty:
+		if value := golium.NewMapFromJSONBytes(body).Get(o.Name); value != nil {
+			return fmt.Sprint(value)
+		}
+	case CSRFSourceBodyPattern:
+		if matches := o.pattern.FindSubmatch(body); matches != nil {
+			return string(matches[1])
+		}
+	}
+	return ""
+}
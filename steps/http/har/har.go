@@ -0,0 +1,300 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package har builds HTTP Archive (HAR 1.2) entries from HTTP requests and responses.
+// See http://www.softwareishard.com/blog/har-12-spec/
+package har
+
+import (
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/TelefonicaTC2Tech/golium/steps/http/timing"
+)
+
+// Version of the HAR format.
+const Version = "1.2"
+
+// HAR is the root of a HAR document.
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log contains the exported HTTP traffic.
+type Log struct {
+	Version string   `json:"version"`
+	Creator Creator  `json:"creator"`
+	Entries []*Entry `json:"entries"`
+}
+
+// Creator identifies the application that created the HAR document.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is an HTTP request with its response.
+type Entry struct {
+	StartedDateTime string   `json:"startedDateTime"`
+	Time            float64  `json:"time"`
+	Request         Request  `json:"request"`
+	Response        Response `json:"response"`
+	Cache           struct{} `json:"cache"`
+	Timings         Timings  `json:"timings"`
+	Comment         string   `json:"comment,omitempty"`
+}
+
+// Request of an entry.
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int64       `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+// Response of an entry.
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int64       `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+// NameValue is a header or a query parameter.
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Cookie of a request or a response.
+type Cookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+// PostData is the body of a request.
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Content is the body of a response. Binary bodies are encoded in base64.
+type Content struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Timings of an entry in milliseconds. The value -1 means that the phase does not apply.
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// Total returns the total time of the timings, excluding the phases that do not apply.
+// The SSL time is included in the connect time.
+func (t Timings) Total() float64 {
+	var total float64
+	for _, d := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if d > 0 {
+			total += d
+		}
+	}
+	return total
+}
+
+// Millis converts a duration to milliseconds.
+func Millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// NewTimings converts the timing of a request into HAR timings.
+// HAR includes the TLS time in the connect time. The send time is included in the wait time.
+func NewTimings(t timing.Timing) Timings {
+	optional := func(d float64) float64 {
+		if d == 0 {
+			return -1
+		}
+		return d
+	}
+	dns, connect, tls := Millis(t.DNS), Millis(t.Connect), Millis(t.TLS)
+	timings := Timings{
+		Blocked: -1,
+		DNS:     optional(dns),
+		Connect: optional(connect + tls),
+		SSL:     optional(tls),
+	}
+	if t.FirstByte > 0 {
+		timings.Wait = Millis(t.FirstByte) - dns - connect - tls
+		if timings.Wait < 0 {
+			timings.Wait = 0
+		}
+		timings.Receive = Millis(t.Total - t.FirstByte)
+	} else {
+		timings.Wait = Millis(t.Total)
+	}
+	return timings
+}
+
+// NewEntry creates an entry from a request and its response (nil if there is no response).
+// The bodies are passed apart because the bodies of the request and response are consumed.
+func NewEntry(started time.Time, req *http.Request, reqBody []byte, resp *http.Response,
+	respBody []byte, timings Timings,
+) *Entry {
+	entry := &Entry{
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Time:            timings.Total(),
+		Request:         newRequest(req, reqBody),
+		Timings:         timings,
+	}
+	if resp != nil {
+		entry.Response = newResponse(resp, respBody)
+	} else {
+		entry.Response = Response{
+			Cookies: []Cookie{}, Headers: []NameValue{}, HeadersSize: -1, BodySize: -1,
+		}
+		entry.Comment = "no response"
+	}
+	return entry
+}
+
+func newRequest(req *http.Request, body []byte) Request {
+	r := Request{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     newCookies(req.Cookies()),
+		Headers:     newNameValues(req.Header),
+		QueryString: newNameValues(req.URL.Query()),
+		HeadersSize: -1,
+		BodySize:    int64(len(body)),
+	}
+	if r.HTTPVersion == "" {
+		r.HTTPVersion = "HTTP/1.1"
+	}
+	if req.Host != "" && req.Host != req.URL.Host && req.Header.Get("Host") == "" {
+		r.Headers = append(r.Headers, NameValue{Name: "Host", Value: req.Host})
+	}
+	if len(body) > 0 {
+		r.PostData = &PostData{MimeType: req.Header.Get("Content-Type"), Text: string(body)}
+	}
+	return r
+}
+
+func newResponse(resp *http.Response, body []byte) Response {
+	statusText := http.StatusText(resp.StatusCode)
+	r := Response{
+		Status:      resp.StatusCode,
+		StatusText:  statusText,
+		HTTPVersion: resp.Proto,
+		Cookies:     newCookies(resp.Cookies()),
+		Headers:     newNameValues(resp.Header),
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    int64(len(body)),
+		Content: Content{
+			Size:     int64(len(body)),
+			MimeType: resp.Header.Get("Content-Type"),
+		},
+	}
+	if len(body) == 0 {
+		return r
+	}
+	if utf8.Valid(body) && isText(r.Content.MimeType) {
+		r.Content.Text = string(body)
+	} else {
+		r.Content.Text = base64.StdEncoding.EncodeToString(body)
+		r.Content.Encoding = "base64"
+	}
+	return r
+}
+
+// isText returns true if the media type is not binary (or unknown).
+func isText(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return true
+	}
+	switch mediaType {
+	case "application/octet-stream", "application/zip", "application/gzip", "application/pdf":
+		return false
+	}
+	for _, prefix := range []string{"image/", "audio/", "video/", "font/"} {
+		if strings.HasPrefix(mediaType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// newNameValues converts headers or query params into a list sorted by name.
+func newNameValues(values map[string][]string) []NameValue {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := []NameValue{}
+	for _, name := range names {
+		for _, value := range values[name] {
+			list = append(list, NameValue{Name: name, Value: value})
+		}
+	}
+	return list
+}
+
+func newCookies(cookies []*http.Cookie) []Cookie {
+	list := make([]Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		cookie := Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			cookie.Expires = c.Expires.Format(time.RFC3339)
+		}
+		list = append(list, cookie)
+	}
+	return list
+}
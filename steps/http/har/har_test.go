@@ -0,0 +1,98 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package har
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TelefonicaTC2Tech/golium/steps/http/timing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEntry(t *testing.T) {
+	started := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	body := []byte(`{"name": "alice"}`)
+	req, err := http.NewRequest(http.MethodPost, "http://localhost:9000/users?tag=a&tag=b",
+		bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Host = "golium.test"
+	req.AddCookie(&http.Cookie{Name: "session", Value: "1"})
+	resp := &http.Response{
+		StatusCode: http.StatusCreated,
+		Proto:      "HTTP/1.1",
+		Header: http.Header{
+			"Content-Type": {"image/png"},
+			"Location":     {"/users/1"},
+			"Set-Cookie":   {"id=1; Path=/; HttpOnly; Secure"},
+		},
+	}
+	timings := Timings{Blocked: -1, DNS: -1, Connect: 2, SSL: -1, Send: 0, Wait: 5, Receive: 1}
+
+	entry := NewEntry(started, req, body, resp, []byte{0x89, 0x50}, timings)
+	require.Equal(t, "2021-06-01T10:00:00Z", entry.StartedDateTime)
+	require.Equal(t, float64(8), entry.Time)
+	require.Equal(t, "http://localhost:9000/users?tag=a&tag=b", entry.Request.URL)
+	require.Equal(t, []NameValue{{Name: "tag", Value: "a"}, {Name: "tag", Value: "b"}},
+		entry.Request.QueryString)
+	require.Equal(t, []NameValue{
+		{Name: "Content-Type", Value: "application/json"},
+		{Name: "Cookie", Value: "session=1"},
+		{Name: "Host", Value: "golium.test"},
+	}, entry.Request.Headers)
+	require.Equal(t, []Cookie{{Name: "session", Value: "1"}}, entry.Request.Cookies)
+	require.Equal(t, &PostData{MimeType: "application/json", Text: string(body)},
+		entry.Request.PostData)
+	require.Equal(t, int64(len(body)), entry.Request.BodySize)
+
+	require.Equal(t, http.StatusCreated, entry.Response.Status)
+	require.Equal(t, "Created", entry.Response.StatusText)
+	require.Equal(t, "/users/1", entry.Response.RedirectURL)
+	require.Equal(t, []Cookie{{Name: "id", Value: "1", Path: "/", HTTPOnly: true, Secure: true}},
+		entry.Response.Cookies)
+	require.Equal(t, Content{Size: 2, MimeType: "image/png", Text: "iVA=", Encoding: "base64"},
+		entry.Response.Content)
+}
+
+func TestNewEntryWithoutResponse(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://localhost:9000", http.NoBody)
+	require.NoError(t, err)
+	entry := NewEntry(time.Now(), req, nil, nil, nil, Timings{Wait: 10})
+	require.Nil(t, entry.Request.PostData)
+	require.Equal(t, "no response", entry.Comment)
+	require.Equal(t, 0, entry.Response.Status)
+	require.Equal(t, float64(10), entry.Time)
+}
+
+func TestNewTimings(t *testing.T) {
+	timings := NewTimings(timing.Timing{
+		DNS:       2 * time.Millisecond,
+		Connect:   3 * time.Millisecond,
+		TLS:       5 * time.Millisecond,
+		FirstByte: 30 * time.Millisecond,
+		Total:     40 * time.Millisecond,
+	})
+	require.Equal(t, Timings{
+		Blocked: -1, DNS: 2, Connect: 8, SSL: 5, Wait: 20, Receive: 10,
+	}, timings)
+	require.Equal(t, float64(40), timings.Total())
+	timings = NewTimings(timing.Timing{FirstByte: 5 * time.Millisecond, Total: 6 * time.Millisecond})
+	require.Equal(t, Timings{
+		Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: 5, Receive: 1,
+	}, timings)
+}
@@ -0,0 +1,286 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package har
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/timing"
+)
+
+const (
+	// CreatorName is the name of the creator of the HAR documents.
+	CreatorName = "golium"
+	// MaxBodySize is the maximum size of the recorded bodies. Bigger bodies are truncated.
+	MaxBodySize = 10 << 20
+
+	modulePath = "github.com/TelefonicaTC2Tech/golium"
+)
+
+// Recorder records the HTTP traffic as HAR entries. It is safe for concurrent use.
+type Recorder struct {
+	entries []*Entry
+	mutex   sync.Mutex
+}
+
+// NewRecorder creates an empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{entries: []*Entry{}}
+}
+
+// Add an entry to the recorder.
+func (r *Recorder) Add(entry *Entry) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+// Entries returns a copy of the recorded entries.
+func (r *Recorder) Entries() []*Entry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	entries := make([]*Entry, len(r.entries))
+	copy(entries, r.entries)
+	return entries
+}
+
+// HAR returns the HAR document with the recorded entries.
+func (r *Recorder) HAR() *HAR {
+	return &HAR{
+		Log: Log{
+			Version: Version,
+			Creator: Creator{Name: CreatorName, Version: creatorVersion()},
+			Entries: r.Entries(),
+		},
+	}
+}
+
+// Save the HAR document in a file, creating its directory if required.
+func (r *Recorder) Save(path string) error {
+	data, err := json.MarshalIndent(r.HAR(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed marshalling HAR document: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed creating directory of HAR file '%s': %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed writing HAR file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// Transport wraps a transport to record the HTTP traffic (nil for http.DefaultTransport).
+func (r *Recorder) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recordingTransport{next: next, recorder: r}
+}
+
+// creatorVersion returns the version of the golium module in the build.
+func creatorVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
+
+// recorderKey is the key of the active recorder in the golium context of the scenario.
+const recorderKey = "golium.har.recorder"
+
+// Start recording the HTTP traffic of the scenario in the recorder.
+// The transports wrapped with Wrap in the same scenario record their traffic in it.
+func Start(ctx context.Context, r *Recorder) {
+	if c, ok := golium.LookupContext(ctx); ok {
+		c.Put(recorderKey, r)
+	}
+}
+
+// Stop recording the HTTP traffic of the scenario.
+func Stop(ctx context.Context) {
+	Start(ctx, nil)
+}
+
+// Current returns the active recorder of the scenario, or nil if its HTTP traffic
+// is not recorded.
+func Current(ctx context.Context) *Recorder {
+	c, ok := golium.LookupContext(ctx)
+	if !ok {
+		return nil
+	}
+	r, _ := c.Get(recorderKey).(*Recorder)
+	return r
+}
+
+// Wrap the transport of an HTTP client to record its traffic in the active recorder
+// of the scenario. It returns the same transport when there is no active recorder.
+// A nil transport is http.DefaultTransport, as in http.Client.
+func Wrap(ctx context.Context, next http.RoundTripper) http.RoundTripper {
+	r := Current(ctx)
+	if r == nil {
+		return next
+	}
+	return r.Transport(next)
+}
+
+// Unwrap returns the transport wrapped by Wrap, or the same transport if it is not wrapped.
+func Unwrap(rt http.RoundTripper) http.RoundTripper {
+	if t, ok := rt.(*recordingTransport); ok {
+		return t.next
+	}
+	return rt
+}
+
+// recordingTransport is a transport that records the requests and responses in a recorder.
+type recordingTransport struct {
+	next     http.RoundTripper
+	recorder *Recorder
+}
+
+// RoundTrip sends the request with the wrapped transport. The entry is added to the recorder
+// when the response body is read until EOF or closed, or when the request fails.
+// The timing is measured with the trace of the request context (see timing.WithTrace),
+// shared with the sender of the request, except for the redirected requests that are
+// measured with their own trace.
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	ctx := req.Context()
+	trace := timing.FromContext(ctx)
+	shared := trace != nil && req.Response == nil
+	if !shared {
+		trace = timing.NewTrace()
+		ctx = timing.WithTrace(ctx, trace)
+	}
+	traced := req.WithContext(ctx)
+	traced.Body = body
+	resp, err := t.next.RoundTrip(traced)
+	if err != nil {
+		entry := NewEntry(trace.Start, req, reqBody, nil, nil, NewTimings(trace.Finish()))
+		entry.Comment = fmt.Sprintf("no response: %s", err)
+		t.recorder.Add(entry)
+		return nil, err
+	}
+	trace.GotResponse()
+	// a shared trace is not finished by a redirection, because the sender measures
+	// the redirected requests too
+	finish := trace.Finish
+	if shared && isRedirect(resp) {
+		finish = trace.Snapshot
+	}
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		done: func(respBody []byte, truncated bool) {
+			entry := NewEntry(trace.Start, req, reqBody, resp, respBody, NewTimings(finish()))
+			if truncated {
+				entry.Comment = fmt.Sprintf("response body truncated to %d bytes", MaxBodySize)
+			}
+			t.recorder.Add(entry)
+		},
+	}
+	return resp, nil
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped transport.
+func (t *recordingTransport) CloseIdleConnections() {
+	if c, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// Close closes the wrapped transport if it is closable (e.g. HTTP/3).
+func (t *recordingTransport) Close() error {
+	if c, ok := t.next.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// isRedirect returns true if the response is a redirection that might be followed
+// by the client.
+func isRedirect(resp *http.Response) bool {
+	return resp.StatusCode >= 300 && resp.StatusCode < 400 && resp.Header.Get("Location") != ""
+}
+
+// readRequestBody reads the request body and returns a new reader with the same content.
+func readRequestBody(req *http.Request) ([]byte, io.ReadCloser, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, req.Body, nil
+	}
+	defer req.Body.Close()
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed reading the request body: %w", err)
+	}
+	return data, io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// recordingBody copies the response body while it is read, and invokes done once
+// when the body is read until EOF or closed.
+type recordingBody struct {
+	io.ReadCloser
+	buf       bytes.Buffer
+	truncated bool
+	once      sync.Once
+	done      func(body []byte, truncated bool)
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if remaining := MaxBodySize - b.buf.Len(); remaining < n {
+			b.buf.Write(p[:max(remaining, 0)])
+			b.truncated = true
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+func (b *recordingBody) finish() {
+	b.once.Do(func() { b.done(b.buf.Bytes(), b.truncated) })
+}
@@ -0,0 +1,194 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package har
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/timing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("echo: " + string(body)))
+	}))
+	defer server.Close()
+	recorder := NewRecorder()
+	client := &http.Client{Transport: recorder.Transport(nil)}
+
+	tcs := []struct {
+		name     string
+		method   string
+		body     string
+		expected string
+	}{
+		{name: "without body", method: http.MethodGet, expected: "echo: "},
+		{name: "with body", method: http.MethodPost, body: "hello", expected: "echo: hello"},
+	}
+	for i, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var body io.Reader
+			if tc.body != "" {
+				body = strings.NewReader(tc.body)
+			}
+			req, err := http.NewRequest(tc.method, server.URL+"/echo", body)
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			respBody, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			require.Equal(t, tc.expected, string(respBody))
+
+			entries := recorder.Entries()
+			require.Len(t, entries, i+1)
+			entry := entries[i]
+			require.Equal(t, tc.method, entry.Request.Method)
+			require.Equal(t, server.URL+"/echo", entry.Request.URL)
+			if tc.body != "" {
+				require.Equal(t, tc.body, entry.Request.PostData.Text)
+			} else {
+				require.Nil(t, entry.Request.PostData)
+			}
+			require.Equal(t, http.StatusCreated, entry.Response.Status)
+			require.Equal(t, tc.expected, entry.Response.Content.Text)
+			require.GreaterOrEqual(t, entry.Timings.Wait, float64(0))
+			require.GreaterOrEqual(t, entry.Timings.Receive, float64(0))
+		})
+	}
+}
+
+func TestRecorderTransportSharedTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/target", http.StatusFound)
+			return
+		}
+		w.Write([]byte("target"))
+	}))
+	defer server.Close()
+	recorder := NewRecorder()
+	client := &http.Client{Transport: recorder.Transport(nil)}
+
+	for i, path := range []string{"/target", "/redirect"} {
+		trace := timing.NewTrace()
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, http.NoBody)
+		require.NoError(t, err)
+		req = req.WithContext(timing.WithTrace(req.Context(), trace))
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_, err = io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		trace.GotResponse()
+		entries := recorder.Entries()
+		require.Len(t, entries, 2*i+1)
+		// the last entry of a redirection is measured with its own trace
+		if path == "/target" {
+			require.Equal(t, NewTimings(trace.Finish()), entries[0].Timings)
+		} else {
+			require.Equal(t, trace.Start.Format(time.RFC3339Nano), entries[1].StartedDateTime)
+			require.Less(t, entries[1].Time, NewTimings(trace.Finish()).Total())
+		}
+	}
+}
+
+func TestRecorderTransportError(t *testing.T) {
+	recorder := NewRecorder()
+	client := &http.Client{Transport: recorder.Transport(nil)}
+	_, err := client.Get("http://127.0.0.1:1/unreachable")
+	require.Error(t, err)
+	entries := recorder.Entries()
+	require.Len(t, entries, 1)
+	require.Contains(t, entries[0].Comment, "no response")
+	require.Equal(t, -1, int(entries[0].Response.BodySize))
+}
+
+func TestWrap(t *testing.T) {
+	ctx := golium.InitializeContext(context.Background())
+	transport := &http.Transport{}
+	require.Equal(t, http.RoundTripper(transport), Wrap(ctx, transport))
+	require.Nil(t, Wrap(ctx, nil))
+	require.Nil(t, Wrap(context.Background(), nil))
+
+	Start(ctx, NewRecorder())
+	wrapped := Wrap(ctx, transport)
+	require.NotEqual(t, http.RoundTripper(transport), wrapped)
+	require.Equal(t, http.RoundTripper(transport), Unwrap(wrapped))
+	require.Equal(t, http.DefaultTransport, Unwrap(Wrap(ctx, nil)))
+
+	Stop(ctx)
+	require.Nil(t, Current(ctx))
+}
+
+func TestRecorderPerScenario(t *testing.T) {
+	ctx1 := golium.InitializeContext(context.Background())
+	ctx2 := golium.InitializeContext(context.Background())
+	r1, r2 := NewRecorder(), NewRecorder()
+	Start(ctx1, r1)
+	Start(ctx2, r2)
+	require.Same(t, r1, Current(ctx1))
+	require.Same(t, r2, Current(ctx2))
+
+	Stop(ctx1)
+	require.Nil(t, Current(ctx1))
+	require.Same(t, r2, Current(ctx2))
+	require.Same(t, SuiteRecorder(), SuiteRecorder())
+}
+
+func TestRecorderSave(t *testing.T) {
+	recorder := NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "http://golium.test/", nil)
+	recorder.Add(NewEntry(time.Now(), req, nil, nil, nil, Timings{}))
+	path := filepath.Join(t.TempDir(), "har", "scenario.har")
+
+	require.NoError(t, recorder.Save(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var doc HAR
+	require.NoError(t, json.Unmarshal(data, &doc))
+	require.Equal(t, Version, doc.Log.Version)
+	require.Equal(t, CreatorName, doc.Log.Creator.Name)
+	require.Len(t, doc.Log.Entries, 1)
+}
+
+func TestFileName(t *testing.T) {
+	tcs := []struct {
+		name     string
+		expected string
+	}{
+		{name: "golium", expected: "golium.har"},
+		{name: "Send a request / check status", expected: "Send_a_request_check_status.har"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, FileName(tc.name))
+		})
+	}
+}
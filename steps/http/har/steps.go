@@ -0,0 +1,141 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package har
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/cucumber/godog"
+)
+
+// Recording modes.
+const (
+	ModeScenario = "scenario"
+	ModeSuite    = "suite"
+)
+
+var (
+	invalidFileChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+	suiteRecorder    *Recorder
+	suiteOnce        sync.Once
+)
+
+// Options contains the configuration of the HAR recording.
+type Options struct {
+	// Mode is "scenario" to write a HAR file per scenario, "suite" to write a HAR file with
+	// the traffic of all the scenarios (rewritten after each scenario), or empty to disable
+	// the recording.
+	Mode string
+	// Dir is the directory of the HAR files.
+	Dir string
+}
+
+// Load the HAR configuration from the environment configuration under the key "har":
+//
+//	har:
+//	  mode: scenario
+//	  dir: ./logs/har
+//
+// The default directory is the subdirectory "har" of the log directory.
+func Load(ctx context.Context) (*Options, error) {
+	options := &Options{
+		Mode: golium.ValueAsStringOrEmpty(ctx, "[CONF:har.mode]"),
+		Dir:  golium.ValueAsStringOrEmpty(ctx, "[CONF:har.dir]"),
+	}
+	switch options.Mode {
+	case "", ModeScenario, ModeSuite:
+	default:
+		return nil, fmt.Errorf("invalid HAR mode '%s'", options.Mode)
+	}
+	if options.Dir == "" {
+		options.Dir = filepath.Join(golium.GetConfig().Log.Directory, "har")
+	}
+	return options, nil
+}
+
+// SuiteRecorder returns the recorder shared by all the scenarios of the suite.
+func SuiteRecorder() *Recorder {
+	suiteOnce.Do(func() { suiteRecorder = NewRecorder() })
+	return suiteRecorder
+}
+
+// FileName returns a file name for a HAR document from a name (e.g. a scenario name).
+func FileName(name string) string {
+	return invalidFileChars.ReplaceAllString(name, "_") + ".har"
+}
+
+// Steps type is the golium.StepsInitializer to record the HTTP traffic in HAR files.
+// The HTTP steps, the DoH queries and the mock HTTP client record their traffic
+// while a recorder is active in the scenario.
+type Steps struct{}
+
+// InitializeSteps adds the HAR hooks and steps to the scenario context.
+// It implements StepsInitializer interface.
+// The recorder is stored in the golium context of the scenario (not in the godog context
+// of the hooks) to be shared with the steps of other packages.
+func (s Steps) InitializeSteps(ctx context.Context, scenCtx *godog.ScenarioContext) context.Context {
+	var path string
+	scenCtx.Before(func(hookCtx context.Context, sc *godog.Scenario) (context.Context, error) {
+		options, err := Load(ctx)
+		if err != nil {
+			return hookCtx, err
+		}
+		switch options.Mode {
+		case ModeScenario:
+			path = filepath.Join(options.Dir, FileName(sc.Name))
+			Start(ctx, NewRecorder())
+		case ModeSuite:
+			path = filepath.Join(options.Dir, FileName(golium.GetConfig().Suite))
+			Start(ctx, SuiteRecorder())
+		default:
+			path = ""
+			Stop(ctx)
+		}
+		return hookCtx, nil
+	})
+	scenCtx.Step(`^the HTTP traffic is recorded in the HAR file "([^"]*)"$`, func(file string) {
+		path = golium.ValueAsString(ctx, file)
+		Start(ctx, NewRecorder())
+	})
+	scenCtx.Step(`^the HAR recording must have "([^"]*)" entries$`, func(count string) error {
+		expected, err := golium.ValueAsInt(ctx, count)
+		if err != nil {
+			return fmt.Errorf("invalid number of entries '%s': %w", count, err)
+		}
+		r := Current(ctx)
+		if r == nil {
+			return errors.New("the HTTP traffic is not recorded")
+		}
+		if actual := len(r.Entries()); actual != expected {
+			return fmt.Errorf("mismatch of HAR entries: expected '%d', actual '%d'", expected, actual)
+		}
+		return nil
+	})
+	scenCtx.After(func(hookCtx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		r := Current(ctx)
+		Stop(ctx)
+		if r == nil || path == "" {
+			return hookCtx, nil
+		}
+		return hookCtx, r.Save(path)
+	})
+	return ctx
+}
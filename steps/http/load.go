@@ -0,0 +1,248 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TelefonicaTC2Tech/golium/steps/http/timing"
+	"github.com/google/uuid"
+)
+
+// LoadResult contains the aggregated results of a load of HTTP requests.
+type LoadResult struct {
+	// Requests is the number of requests sent.
+	Requests int
+	// StatusCodes is the distribution of the status codes of the responses.
+	StatusCodes map[int]int
+	// Errors is the number of requests without response (e.g. connection error or timeout).
+	Errors int
+	// Elapsed is the duration of the whole load.
+	Elapsed time.Duration
+}
+
+// ErrorRate returns the percentage of failed requests. A request is failed if there is
+// no response or if the status code is 5xx.
+func (r *LoadResult) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	failed := r.Errors
+	for code, count := range r.StatusCodes {
+		if code >= http.StatusInternalServerError {
+			failed += count
+		}
+	}
+	return float64(failed) * 100 / float64(r.Requests)
+}
+
+// StatusCodeRate returns the percentage of responses with the status code.
+func (r *LoadResult) StatusCodeRate(code int) float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.StatusCodes[code]) * 100 / float64(r.Requests)
+}
+
+// Throughput returns the number of requests per second.
+func (r *LoadResult) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Elapsed.Seconds()
+}
+
+func (r *LoadResult) String() string {
+	codes := make([]int, 0, len(r.StatusCodes))
+	for code := range r.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	distribution := make([]string, 0, len(codes))
+	for _, code := range codes {
+		distribution = append(distribution, fmt.Sprintf("%d: %d", code, r.StatusCodes[code]))
+	}
+	return fmt.Sprintf("requests: %d, errors: %d, status codes: {%s}, elapsed: %s",
+		r.Requests, r.Errors, strings.Join(distribution, ", "), r.Elapsed)
+}
+
+type loadResponse struct {
+	statusCode int
+	timing     Timing
+	timedout   bool
+	err        error
+}
+
+// SendHTTPLoad sends the configured HTTP request the number of times with the concurrency
+// (number of parallel requests). The results are stored in Load, and the timings of the
+// requests with response replace the session timings, so that the response time
+// percentiles are calculated for the load. The session timing is the one of the last
+// response received.
+func (s *Session) SendHTTPLoad(ctx context.Context, method string, count, concurrency int,
+) error {
+	if count < 1 {
+		return fmt.Errorf("invalid number of HTTP requests '%d'", count)
+	}
+	if concurrency < 1 {
+		return fmt.Errorf("invalid concurrency '%d'", concurrency)
+	}
+	if s.Request.MultipartBody != nil {
+		return errors.New("multipart body is not supported in HTTP load")
+	}
+	s.Request.Method = method
+	client, err := s.newHTTPClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeHTTPClient(client)
+	// Validate the request before starting the load
+	if _, err := s.newRequest(ctx, method); err != nil {
+		return err
+	}
+	jobs := make(chan struct{}, count)
+	for i := 0; i < count; i++ {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+	responses := make(chan loadResponse, count)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < concurrency && i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				responses <- s.sendLoadRequest(ctx, client, method)
+			}
+		}()
+	}
+	wg.Wait()
+	close(responses)
+	result := &LoadResult{Requests: count, StatusCodes: make(map[int]int)}
+	result.Elapsed = time.Since(start)
+	s.Timing = Timing{}
+	s.Timings = nil
+	s.Timeouts = 0
+	for resp := range responses {
+		if resp.err != nil {
+			result.Errors++
+			if resp.timedout {
+				s.Timeouts++
+			}
+			continue
+		}
+		result.StatusCodes[resp.statusCode]++
+		s.Timing = resp.timing
+		s.Timings = append(s.Timings, resp.timing)
+	}
+	s.Load = result
+	GetLogger().Log.Printf("Load [%s] %s %s with concurrency %d:\n%s\n\n",
+		uuid.New().String(), method, s.Request.Endpoint, concurrency, result)
+	return nil
+}
+
+func (s *Session) sendLoadRequest(ctx context.Context, client *http.Client, method string,
+) loadResponse {
+	req, err := s.newRequest(ctx, method)
+	if err != nil {
+		return loadResponse{err: err}
+	}
+	trace := timing.NewTrace()
+	req = req.WithContext(timing.WithTrace(req.Context(), trace))
+	resp, err := client.Do(req)
+	if err != nil {
+		netErr, ok := err.(net.Error)
+		return loadResponse{err: err, timedout: ok && netErr.Timeout()}
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return loadResponse{err: err}
+	}
+	return loadResponse{statusCode: resp.StatusCode, timing: trace.Finish()}
+}
+
+func (s *Session) loadResult() (*LoadResult, error) {
+	if s.Load == nil {
+		return nil, errors.New("no HTTP load sent")
+	}
+	return s.Load, nil
+}
+
+// ValidateLoadErrorRate validates that the percentage of failed requests of the load is less
+// than the maximum.
+func (s *Session) ValidateLoadErrorRate(ctx context.Context, maxRate float64) error {
+	load, err := s.loadResult()
+	if err != nil {
+		return err
+	}
+	if rate := load.ErrorRate(); rate >= maxRate {
+		return fmt.Errorf("HTTP load error rate '%.2f%%' is not less than '%v%%' (%s)",
+			rate, maxRate, load)
+	}
+	return nil
+}
+
+// ValidateLoadStatusCodeRate validates that the percentage of responses of the load with
+// the status code is, at least, the minimum.
+func (s *Session) ValidateLoadStatusCodeRate(ctx context.Context, code int, minRate float64,
+) error {
+	load, err := s.loadResult()
+	if err != nil {
+		return err
+	}
+	if rate := load.StatusCodeRate(code); rate < minRate {
+		return fmt.Errorf("HTTP load status code '%d' rate '%.2f%%' is less than '%v%%' (%s)",
+			code, rate, minRate, load)
+	}
+	return nil
+}
+
+// ValidateLoadStatusCodes validates the distribution of status codes of the load.
+func (s *Session) ValidateLoadStatusCodes(ctx context.Context, expected map[int]int) error {
+	load, err := s.loadResult()
+	if err != nil {
+		return err
+	}
+	for code, count := range expected {
+		if load.StatusCodes[code] != count {
+			return fmt.Errorf("HTTP load status code '%d' mismatch: expected '%d' responses, "+
+				"actual '%d' (%s)", code, count, load.StatusCodes[code], load)
+		}
+	}
+	return nil
+}
+
+// ValidateLoadThroughput validates that the throughput of the load, in requests per second,
+// is at least the minimum.
+func (s *Session) ValidateLoadThroughput(ctx context.Context, minThroughput float64) error {
+	load, err := s.loadResult()
+	if err != nil {
+		return err
+	}
+	if throughput := load.Throughput(); throughput < minThroughput {
+		return fmt.Errorf("HTTP load throughput '%.2f' requests per second is less than '%v'",
+			throughput, minThroughput)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendHTTPLoad(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	var received, inFlight, maxInFlight int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if current <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		if r.Header.Get("X-Load") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// Every tenth request fails
+		if atomic.AddInt32(&received, 1)%10 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	ctx := context.Background()
+	s := &Session{}
+	s.ConfigureEndpoint(ctx, ts.URL)
+	s.ConfigureHeaders(ctx, map[string][]string{"X-Load": {"true"}})
+
+	require.Error(t, s.ValidateLoadErrorRate(ctx, 1))
+	require.Error(t, s.SendHTTPLoad(ctx, http.MethodGet, 0, 1))
+	require.Error(t, s.SendHTTPLoad(ctx, http.MethodGet, 1, 0))
+	require.NoError(t, s.SendHTTPLoad(ctx, http.MethodGet, 100, 10))
+	require.LessOrEqual(t, maxInFlight, int32(10))
+	require.Greater(t, maxInFlight, int32(1))
+
+	require.Equal(t, 100, s.Load.Requests)
+	require.Equal(t, 0, s.Load.Errors)
+	require.Len(t, s.Timings, 100)
+	require.Equal(t, s.Timings[99], s.Timing)
+	require.NoError(t, s.ValidateResponseTime(ctx, "", time.Second))
+	require.NoError(t, s.ValidateLoadStatusCodes(ctx, map[int]int{
+		http.StatusOK:                 90,
+		http.StatusServiceUnavailable: 10,
+	}))
+	require.Error(t, s.ValidateLoadStatusCodes(ctx, map[int]int{http.StatusOK: 100}))
+	require.NoError(t, s.ValidateLoadErrorRate(ctx, 11))
+	require.Error(t, s.ValidateLoadErrorRate(ctx, 10))
+	require.NoError(t, s.ValidateLoadStatusCodeRate(ctx, http.StatusOK, 90))
+	require.Error(t, s.ValidateLoadStatusCodeRate(ctx, http.StatusOK, 91))
+	require.NoError(t, s.ValidateLoadThroughput(ctx, 1))
+	require.NoError(t, s.ValidateResponseTimePercentile(ctx, "", 95, time.Second))
+	require.Error(t, s.ValidateResponseTimePercentile(ctx, "", 95, time.Millisecond))
+	// The headers of the session are not modified by the requests
+	require.Equal(t, map[string][]string{"X-Load": {"true"}}, s.Request.Headers)
+}
+
+func TestSendHTTPLoadWithErrors(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	endpoint := ts.URL
+	ts.Close()
+	ctx := context.Background()
+	s := &Session{}
+	s.ConfigureEndpoint(ctx, endpoint)
+	require.NoError(t, s.SendHTTPLoad(ctx, http.MethodGet, 5, 2))
+	require.Equal(t, 5, s.Load.Errors)
+	require.Equal(t, 0, s.Timeouts)
+	require.Error(t, s.ValidateResponseTime(ctx, "", time.Second))
+	require.InDelta(t, 100.0, s.Load.ErrorRate(), 0.001)
+	require.Error(t, s.ValidateLoadErrorRate(ctx, 1))
+}
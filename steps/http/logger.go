@@ -15,11 +15,13 @@
 package http
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/har"
 )
 
 var httpLog *Logger
@@ -62,6 +64,30 @@ func (l Logger) LogResponse(resp *http.Response, body []byte, corr string) {
 		getBody(body))
 }
 
+// LogCurl logs the curl command to reproduce an HTTP request in the configured log file.
+func (l Logger) LogCurl(command, corr string) {
+	l.Log.Printf("cURL [%s]:\n%s\n\n", corr, command)
+}
+
+// LogHAREntry logs an HTTP request and response as a HAR entry in the configured log file.
+func (l Logger) LogHAREntry(entry *har.Entry, corr string) {
+	obfuscated := *entry
+	obfuscated.Request.Headers = obfuscateNameValues(entry.Request.Headers)
+	obfuscated.Response.Headers = obfuscateNameValues(entry.Response.Headers)
+	b, err := json.MarshalIndent(obfuscated, "", "  ")
+	if err != nil {
+		l.Log.Printf("HAR entry [%s]: %s\n\n", corr, err)
+		return
+	}
+	l.Log.Printf("HAR entry [%s]:\n%s\n\n", corr, b)
+}
+
+// LogStreamEvent logs an event received in an HTTP stream in the configured log file.
+func (l Logger) LogStreamEvent(event *StreamEvent, corr string) {
+	l.Log.Printf("Stream event [%s]:\nid: %s\nevent: %s\ndata: %s\n\n",
+		corr, event.ID, event.Type, event.Data)
+}
+
 // LogTimeout logs an HTTP response with timeout in the configured log file.
 func (l Logger) LogTimeout(corr string) {
 	l.Log.Print("Response: Timeout\n\n")
@@ -79,15 +105,31 @@ func getHeaders(headers map[string][]string) string {
 	var fmtHeaders []string
 	for key, values := range headers {
 		for _, value := range values {
-			if _, ok := AuthHeaders[key]; ok {
-				value = httpLog.Log.Obfuscate(value)
-			}
-			fmtHeaders = append(fmtHeaders, fmt.Sprintf("%s: %s", key, value))
+			fmtHeaders = append(fmtHeaders, fmt.Sprintf("%s: %s", key, obfuscateHeader(key, value)))
 		}
 	}
 	return strings.Join(fmtHeaders, "\n")
 }
 
+// obfuscateHeader obfuscates the value of the authorization headers.
+func obfuscateHeader(name, value string) string {
+	if _, ok := AuthHeaders[name]; ok {
+		return GetLogger().Log.Obfuscate(value)
+	}
+	return value
+}
+
+func obfuscateNameValues(headers []har.NameValue) []har.NameValue {
+	obfuscated := make([]har.NameValue, len(headers))
+	for i, header := range headers {
+		obfuscated[i] = har.NameValue{
+			Name:  header.Name,
+			Value: obfuscateHeader(header.Name, header.Value),
+		}
+	}
+	return obfuscated
+}
+
 func getBody(body []byte) string {
 	if len(body) == 0 {
 		return ""
@@ -62,6 +62,32 @@ func (r *Request) AddBody(message interface{}) {
 	r.RequestBody, _ = json.Marshal(message)
 }
 
+// Clone returns a copy of the request that can be modified and sent again.
+// The multipart body is copied without consuming it.
+func (r *Request) Clone() *Request {
+	clone := *r
+	clone.Headers = cloneMultiMap(r.Headers)
+	clone.QueryParams = cloneMultiMap(r.QueryParams)
+	if r.RequestBody != nil {
+		clone.RequestBody = append([]byte{}, r.RequestBody...)
+	}
+	if r.MultipartBody != nil {
+		clone.MultipartBody = bytes.NewBuffer(append([]byte{}, r.MultipartBody.Bytes()...))
+	}
+	return &clone
+}
+
+func cloneMultiMap(m map[string][]string) map[string][]string {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[string][]string, len(m))
+	for k, v := range m {
+		clone[k] = append([]string{}, v...)
+	}
+	return clone
+}
+
 func (r *Request) AddMultipartBody(mBody bytes.Buffer) {
 	r.MultipartBody = &mBody
 }
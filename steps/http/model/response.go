@@ -8,4 +8,12 @@ type Response struct {
 	HTTPResponse *http.Response
 	// Response body as slice of bytes
 	ResponseBody []byte
+	// Path of the file with the response body when it is streamed to a file
+	BodyFile string
+	// Size of the response body after decoding the content encoding
+	BodySize int64
+	// Size of the response body as received, before decoding it (-1 if unknown)
+	EncodedBodySize int64
+	// Content encoding of the response body (e.g. gzip), empty if not encoded
+	ContentEncoding string
 }
@@ -0,0 +1,230 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TelefonicaTC2Tech/golium"
+)
+
+const (
+	confOAuth2Profile = "[CONF:oauth2.%s.%s]"
+
+	OAuth2GrantClientCredentials = "client_credentials"
+	OAuth2GrantPassword          = "password"
+	OAuth2GrantRefreshToken      = "refresh_token"
+
+	// oauth2ExpiryDelta is subtracted from the token expiration to refresh it
+	// before the authorization server considers it expired.
+	oauth2ExpiryDelta = 10 * time.Second
+)
+
+// OAuth2Profile contains the configuration to request OAuth2 tokens to an authorization server.
+// It is loaded from the environment configuration under the key "oauth2.{profile}":
+//
+//	oauth2:
+//	  admin:
+//	    token-url: http://localhost:9000/oauth2/token
+//	    grant-type: password
+//	    client-id: golium
+//	    client-secret: secret
+//	    username: admin
+//	    password: admin
+//	    scope: read write
+type OAuth2Profile struct {
+	Name         string
+	TokenURL     string
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Username     string
+	Password     string
+	Scope        string
+}
+
+// OAuth2Token is the token response of the authorization server.
+type OAuth2Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresIn    int       `json:"expires_in"`
+	Expiry       time.Time `json:"-"`
+}
+
+// Valid returns true if the token has an access token that is not expired yet.
+// A token without expiration is always valid.
+func (t *OAuth2Token) Valid() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || time.Now().Add(oauth2ExpiryDelta).Before(t.Expiry)
+}
+
+// oauth2TokenCache stores the OAuth2 tokens by profile name.
+// The cache is shared by all the scenarios of the suite.
+type oauth2TokenCache struct {
+	tokens map[string]*OAuth2Token
+	mutex  sync.Mutex
+}
+
+var oauth2Tokens = &oauth2TokenCache{tokens: make(map[string]*OAuth2Token)}
+
+// LoadOAuth2Profile loads an OAuth2 profile from the environment configuration.
+func LoadOAuth2Profile(ctx context.Context, name string) (*OAuth2Profile, error) {
+	profile := &OAuth2Profile{
+		Name:     name,
+		TokenURL: golium.ValueAsStringOrEmpty(ctx, fmt.Sprintf(confOAuth2Profile, name, "token-url")),
+		GrantType: golium.ValueAsStringOrEmpty(ctx,
+			fmt.Sprintf(confOAuth2Profile, name, "grant-type")),
+		ClientID: golium.ValueAsStringOrEmpty(ctx, fmt.Sprintf(confOAuth2Profile, name, "client-id")),
+		ClientSecret: golium.ValueAsStringOrEmpty(ctx,
+			fmt.Sprintf(confOAuth2Profile, name, "client-secret")),
+		Username: golium.ValueAsStringOrEmpty(ctx, fmt.Sprintf(confOAuth2Profile, name, "username")),
+		Password: golium.ValueAsStringOrEmpty(ctx, fmt.Sprintf(confOAuth2Profile, name, "password")),
+		Scope:    golium.ValueAsStringOrEmpty(ctx, fmt.Sprintf(confOAuth2Profile, name, "scope")),
+	}
+	if profile.TokenURL == "" {
+		return nil, fmt.Errorf("missing token-url in OAuth2 profile '%s'", name)
+	}
+	if profile.GrantType == "" {
+		profile.GrantType = OAuth2GrantClientCredentials
+	}
+	return profile, nil
+}
+
+// ConfigureOAuth2 configures the OAuth2 profile to authorize the HTTP requests.
+// The token is requested immediately to fail fast if the profile is not valid.
+func (s *Session) ConfigureOAuth2(ctx context.Context, profile *OAuth2Profile) error {
+	switch profile.GrantType {
+	case OAuth2GrantClientCredentials, OAuth2GrantPassword:
+	default:
+		return fmt.Errorf("unsupported OAuth2 grant type '%s'", profile.GrantType)
+	}
+	s.OAuth2 = profile
+	if _, err := s.OAuth2Token(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// OAuth2Token returns the token of the configured OAuth2 profile.
+// The token is cached per profile and it is only requested again when it is expired.
+// If the expired token contains a refresh token, the refresh_token grant is tried first.
+func (s *Session) OAuth2Token(ctx context.Context) (*OAuth2Token, error) {
+	if s.OAuth2 == nil {
+		return nil, fmt.Errorf("no OAuth2 profile configured")
+	}
+	oauth2Tokens.mutex.Lock()
+	defer oauth2Tokens.mutex.Unlock()
+	token := oauth2Tokens.tokens[s.OAuth2.Name]
+	if token.Valid() {
+		return token, nil
+	}
+	var err error
+	if token != nil && token.RefreshToken != "" {
+		form := url.Values{
+			"grant_type":    {OAuth2GrantRefreshToken},
+			"refresh_token": {token.RefreshToken},
+		}
+		if token, err = s.requestOAuth2Token(ctx, form); err == nil {
+			oauth2Tokens.tokens[s.OAuth2.Name] = token
+			return token, nil
+		}
+		GetLogger().Log.Infof("Failed refreshing OAuth2 token of profile '%s': %s",
+			s.OAuth2.Name, err)
+	}
+	form := url.Values{"grant_type": {s.OAuth2.GrantType}}
+	if s.OAuth2.GrantType == OAuth2GrantPassword {
+		form.Set("username", s.OAuth2.Username)
+		form.Set("password", s.OAuth2.Password)
+	}
+	if s.OAuth2.Scope != "" {
+		form.Set("scope", s.OAuth2.Scope)
+	}
+	if token, err = s.requestOAuth2Token(ctx, form); err != nil {
+		return nil, err
+	}
+	oauth2Tokens.tokens[s.OAuth2.Name] = token
+	return token, nil
+}
+
+func (s *Session) requestOAuth2Token(ctx context.Context, form url.Values) (*OAuth2Token, error) {
+	if s.OAuth2.ClientID != "" {
+		form.Set("client_id", s.OAuth2.ClientID)
+	}
+	if s.OAuth2.ClientSecret != "" {
+		form.Set("client_secret", s.OAuth2.ClientSecret)
+	}
+	req, err := http.NewRequest(http.MethodPost, s.OAuth2.TokenURL,
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed creating the OAuth2 token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	client, err := s.newHTTPClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeHTTPClient(client)
+	// The cookies of the session are not shared with the authorization server.
+	client.Jar = nil
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed requesting OAuth2 token to '%s': %w", s.OAuth2.TokenURL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading the OAuth2 token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OAuth2 token request for profile '%s' failed with status '%d': %s",
+			s.OAuth2.Name, resp.StatusCode, body)
+	}
+	var token OAuth2Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("failed unmarshalling the OAuth2 token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("OAuth2 token response without access_token")
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return &token, nil
+}
+
+// authorizeOAuth2 adds the bearer token of the OAuth2 profile (if configured) to the request.
+func (s *Session) authorizeOAuth2(ctx context.Context, req *http.Request) error {
+	if s.OAuth2 == nil {
+		return nil
+	}
+	token, err := s.OAuth2Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed authorizing the HTTP request with OAuth2: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
+	return nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTokenServer creates a local stand-in of an OAuth2 token endpoint.
+// It returns a new access token for each request and counts the requests by grant type.
+func newTokenServer(t *testing.T, calls map[string]*int32) *httptest.Server {
+	var counter int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.PostForm.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		grantType := r.PostForm.Get("grant_type")
+		if c, ok := calls[grantType]; ok {
+			atomic.AddInt32(c, 1)
+		}
+		if grantType == OAuth2GrantPassword && r.PostForm.Get("password") != "admin" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := atomic.AddInt32(&counter, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w,
+			`{"access_token":"token-%d","token_type":"Bearer","expires_in":3600,"refresh_token":"r"}`, n)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestConfigureOAuth2(t *testing.T) {
+	calls := map[string]*int32{
+		OAuth2GrantClientCredentials: new(int32),
+		OAuth2GrantPassword:          new(int32),
+	}
+	server := newTokenServer(t, calls)
+	tests := []struct {
+		name    string
+		profile OAuth2Profile
+		wantErr bool
+	}{
+		{
+			name: "client credentials grant",
+			profile: OAuth2Profile{
+				Name: "client", TokenURL: server.URL, GrantType: OAuth2GrantClientCredentials,
+				ClientID: "golium", ClientSecret: "secret",
+			},
+			wantErr: false,
+		},
+		{
+			name: "password grant",
+			profile: OAuth2Profile{
+				Name: "admin", TokenURL: server.URL, GrantType: OAuth2GrantPassword,
+				ClientID: "golium", ClientSecret: "secret", Username: "admin", Password: "admin",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid client secret",
+			profile: OAuth2Profile{
+				Name: "invalid", TokenURL: server.URL, GrantType: OAuth2GrantClientCredentials,
+				ClientID: "golium", ClientSecret: "invalid",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported grant type",
+			profile: OAuth2Profile{
+				Name: "implicit", TokenURL: server.URL, GrantType: "implicit",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oauth2Tokens.tokens = make(map[string]*OAuth2Token)
+			s := &Session{}
+			profile := tt.profile
+			if err := s.ConfigureOAuth2(context.Background(), &profile); (err != nil) != tt.wantErr {
+				t.Errorf("Session.ConfigureOAuth2() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	require.Equal(t, int32(1), *calls[OAuth2GrantClientCredentials])
+	require.Equal(t, int32(1), *calls[OAuth2GrantPassword])
+}
+
+func TestOAuth2TokenCacheAndRefresh(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	calls := map[string]*int32{
+		OAuth2GrantClientCredentials: new(int32),
+		OAuth2GrantRefreshToken:      new(int32),
+	}
+	server := newTokenServer(t, calls)
+	oauth2Tokens.tokens = make(map[string]*OAuth2Token)
+	ctx := context.Background()
+	profile := &OAuth2Profile{
+		Name: "cached", TokenURL: server.URL, GrantType: OAuth2GrantClientCredentials,
+		ClientSecret: "secret",
+	}
+	s := &Session{}
+	require.NoError(t, s.ConfigureOAuth2(ctx, profile))
+	// A new session of the suite reuses the cached token
+	other := &Session{OAuth2: profile}
+	token, err := other.OAuth2Token(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token.AccessToken)
+	require.Equal(t, int32(1), *calls[OAuth2GrantClientCredentials])
+	// An expired token is refreshed
+	token.Expiry = time.Now().Add(-time.Minute)
+	token, err = other.OAuth2Token(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "token-2", token.AccessToken)
+	require.Equal(t, int32(1), *calls[OAuth2GrantRefreshToken])
+	require.Equal(t, int32(1), *calls[OAuth2GrantClientCredentials])
+}
+
+func TestSendHTTPRequestWithOAuth2(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	tokenServer := newTokenServer(t, map[string]*int32{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	oauth2Tokens.tokens = make(map[string]*OAuth2Token)
+	ctx := context.Background()
+	s := &Session{}
+	s.Request.Endpoint = server.URL
+	require.NoError(t, s.ConfigureOAuth2(ctx, &OAuth2Profile{
+		Name: "send", TokenURL: tokenServer.URL, GrantType: OAuth2GrantClientCredentials,
+		ClientSecret: "secret",
+	}))
+	require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+	require.NoError(t, s.ValidateStatusCode(ctx, http.StatusNoContent))
+}
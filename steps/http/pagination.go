@@ -0,0 +1,299 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/tidwall/gjson"
+)
+
+// DefaultMaxPages is the maximum number of pages followed when Pagination.MaxPages is not set.
+const DefaultMaxPages = 100
+
+var linkNextRegexp = regexp.MustCompile(
+	`<([^>]*)>[^,]*;\s*rel=(?:"(?:[^"]*\s)?next(?:\s[^"]*)?"|next(?:[;,\s]|$))`)
+
+// Pagination configures how to follow the pages of an HTTP collection.
+// By default, the URL of the next page is obtained from the Link header (rel="next").
+type Pagination struct {
+	// Next is the JSON path of the URL of the next page in the response body.
+	Next string
+	// OffsetParam enables the offset/limit pagination with this query param. The offset is
+	// incremented with the number of items of each page until a page has fewer items than
+	// Limit (or no items if there is no limit).
+	OffsetParam string
+	// LimitParam is the query param with the page size in the offset/limit pagination.
+	LimitParam string
+	// Limit is the page size. If not set, the value of LimitParam in the request is used.
+	Limit int
+	// MaxPages is the maximum number of pages, including the current response.
+	MaxPages int
+}
+
+// FollowPagination collects the items of the JSON path from the current HTTP response and
+// the next pages. The pages are requested with the same method, headers and body.
+// The items are stored in the session collection and in the context. The session request
+// is restored when the pagination ends, and the session response is the last page.
+func (s *Session) FollowPagination(ctx context.Context, itemsPath, ctxtKey string,
+	pagination Pagination,
+) error {
+	if s.Response.HTTPResponse == nil {
+		return errors.New("no HTTP response to paginate")
+	}
+	maxPages := pagination.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+	limit, offset, err := s.offsetLimit(pagination)
+	if err != nil {
+		return err
+	}
+	sessionRequest := s.Request
+	defer func() { s.Request = sessionRequest }()
+	method := s.Request.Method
+	var items []interface{}
+	for page := 1; ; page++ {
+		pageItems, err := s.pageItems(itemsPath)
+		if err != nil {
+			return fmt.Errorf("failed collecting items of page %d: %w", page, err)
+		}
+		items = append(items, pageItems...)
+		if page >= maxPages {
+			break
+		}
+		next, err := s.nextPage(pagination, limit, offset, len(pageItems))
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			break
+		}
+		offset += len(pageItems)
+		endpoint := *next
+		endpoint.RawQuery = ""
+		endpoint.ForceQuery = false
+		s.Request.Endpoint = endpoint.String()
+		s.Request.Path = ""
+		s.Request.QueryParams = next.Query()
+		s.Timedout = false
+		if err := s.SendHTTPRequest(ctx, method); err != nil {
+			return err
+		}
+		if s.Timedout {
+			return fmt.Errorf("timeout requesting page %d of the HTTP collection", page+1)
+		}
+		if status := s.Response.HTTPResponse.StatusCode; status < 200 || status > 299 {
+			return fmt.Errorf("failed requesting page %d of the HTTP collection: status code %d",
+				page+1, status)
+		}
+	}
+	s.Collection = items
+	golium.GetContext(ctx).Put(ctxtKey, items)
+	return nil
+}
+
+// offsetLimit returns the page size and the offset of the current request.
+func (s *Session) offsetLimit(pagination Pagination) (int, int, error) {
+	if pagination.OffsetParam == "" {
+		return 0, 0, nil
+	}
+	param := func(name string) (int, error) {
+		values := s.Request.QueryParams[name]
+		if name == "" || len(values) == 0 {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(values[0])
+		if err != nil {
+			return 0, fmt.Errorf("invalid query param '%s': %w", name, err)
+		}
+		return n, nil
+	}
+	offset, err := param(pagination.OffsetParam)
+	if err != nil {
+		return 0, 0, err
+	}
+	limit := pagination.Limit
+	if limit == 0 {
+		if limit, err = param(pagination.LimitParam); err != nil {
+			return 0, 0, err
+		}
+	}
+	return limit, offset, nil
+}
+
+// pageItems returns the items of the JSON path in the response body. An empty path
+// means that the body is the list of items.
+func (s *Session) pageItems(itemsPath string) ([]interface{}, error) {
+	if itemsPath == "" {
+		itemsPath = "@this"
+	}
+	result := gjson.GetBytes(s.Response.ResponseBody, itemsPath)
+	if !result.Exists() || result.Type == gjson.Null {
+		return nil, nil
+	}
+	if !result.IsArray() {
+		return nil, fmt.Errorf("JSON property '%s' is not a list", itemsPath)
+	}
+	items := []interface{}{}
+	for _, item := range result.Array() {
+		items = append(items, item.Value())
+	}
+	return items, nil
+}
+
+// nextPage returns the URL of the next page, or nil if there are no more pages.
+func (s *Session) nextPage(pagination Pagination, limit, offset, count int) (*url.URL, error) {
+	current := s.Response.HTTPResponse.Request.URL
+	var next string
+	switch {
+	case pagination.OffsetParam != "":
+		if count == 0 || (limit > 0 && count < limit) {
+			return nil, nil
+		}
+		u := *current
+		query := u.Query()
+		query.Set(pagination.OffsetParam, strconv.Itoa(offset+count))
+		if pagination.LimitParam != "" && limit > 0 {
+			query.Set(pagination.LimitParam, strconv.Itoa(limit))
+		}
+		u.RawQuery = query.Encode()
+		return &u, nil
+	case pagination.Next != "":
+		next = gjson.GetBytes(s.Response.ResponseBody, pagination.Next).String()
+	default:
+		next = LinkNext(s.Response.HTTPResponse.Header.Values("Link"))
+	}
+	if next == "" {
+		return nil, nil
+	}
+	u, err := current.Parse(next)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL of the next page '%s': %w", next, err)
+	}
+	return u, nil
+}
+
+// LinkNext returns the URL of the link with the relation "next" in Link headers (RFC 8288),
+// or an empty string if there is no such link.
+func LinkNext(headers []string) string {
+	for _, header := range headers {
+		if match := linkNextRegexp.FindStringSubmatch(header); match != nil {
+			return strings.TrimSpace(match[1])
+		}
+	}
+	return ""
+}
+
+// ValidateCollectionCount validates the number of items of the HTTP collection.
+func (s *Session) ValidateCollectionCount(ctx context.Context, expected int) error {
+	if actual := len(s.Collection); actual != expected {
+		return fmt.Errorf("mismatch of HTTP collection items: expected '%d', actual '%d'",
+			expected, actual)
+	}
+	return nil
+}
+
+// ValidateCollectionUnique validates that the JSON property of the items of the HTTP
+// collection has unique values. An empty property compares the whole items.
+func (s *Session) ValidateCollectionUnique(ctx context.Context, property string) error {
+	seen := make(map[string]int, len(s.Collection))
+	for i, item := range s.Collection {
+		value, err := collectionValue(item, property)
+		if err != nil {
+			return err
+		}
+		key, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed marshalling item %d of the HTTP collection: %w", i, err)
+		}
+		if j, found := seen[string(key)]; found {
+			return fmt.Errorf("duplicated value '%s' of '%s' in the HTTP collection items %d and %d",
+				key, property, j, i)
+		}
+		seen[string(key)] = i
+	}
+	return nil
+}
+
+// ValidateCollectionSorted validates that the items of the HTTP collection are sorted by
+// a JSON property (numbers or strings), in ascending or descending order.
+func (s *Session) ValidateCollectionSorted(ctx context.Context, property string,
+	descending bool,
+) error {
+	for i := 1; i < len(s.Collection); i++ {
+		previous, err := collectionValue(s.Collection[i-1], property)
+		if err != nil {
+			return err
+		}
+		value, err := collectionValue(s.Collection[i], property)
+		if err != nil {
+			return err
+		}
+		cmp, err := compareValues(previous, value)
+		if err != nil {
+			return fmt.Errorf("failed comparing items %d and %d of the HTTP collection: %w",
+				i-1, i, err)
+		}
+		if (!descending && cmp > 0) || (descending && cmp < 0) {
+			return fmt.Errorf("HTTP collection not sorted by '%s': item %d '%v' and item %d '%v'",
+				property, i-1, previous, i, value)
+		}
+	}
+	return nil
+}
+
+// collectionValue returns the value of a JSON property of an item of the collection.
+func collectionValue(item interface{}, property string) (interface{}, error) {
+	if property == "" {
+		return item, nil
+	}
+	b, err := json.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed marshalling item of the HTTP collection: %w", err)
+	}
+	return gjson.GetBytes(b, property).Value(), nil
+}
+
+// compareValues compares two numbers or two strings.
+func compareValues(a, b interface{}) (int, error) {
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1, nil
+			case x > y:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), nil
+		}
+	}
+	return 0, fmt.Errorf("values '%v' (%s) and '%v' (%s) are not comparable",
+		a, reflect.TypeOf(a), b, reflect.TypeOf(b))
+}
@@ -0,0 +1,220 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/stretchr/testify/require"
+)
+
+// newPaginatedServer serves 5 users with id 1..5 in pages of 2 items.
+func newPaginatedServer() *httptest.Server {
+	page := func(offset int) []map[string]interface{} {
+		items := []map[string]interface{}{}
+		for id := offset + 1; id <= offset+2 && id <= 5; id++ {
+			items = append(items, map[string]interface{}{"id": id, "name": fmt.Sprintf("user%d", id)})
+		}
+		return items
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/link", func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if offset+2 < 5 {
+			w.Header().Add("Link", `</link?offset=0>; rel="first"`)
+			w.Header().Add("Link", fmt.Sprintf(`</link?offset=%d>; rel="next"`, offset+2))
+		}
+		json.NewEncoder(w).Encode(page(offset))
+	})
+	mux.HandleFunc("/next", func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		body := map[string]interface{}{"data": page(offset)}
+		if offset+2 < 5 {
+			body["links"] = map[string]interface{}{"next": fmt.Sprintf("next?offset=%d", offset+2)}
+		}
+		json.NewEncoder(w).Encode(body)
+	})
+	mux.HandleFunc("/offset", func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": page(offset)})
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") != "" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Header().Add("Link", `</slow?page=2>; rel="next"`)
+		json.NewEncoder(w).Encode(page(0))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestFollowPagination(t *testing.T) {
+	server := newPaginatedServer()
+	defer server.Close()
+	tcs := []struct {
+		name       string
+		path       string
+		query      map[string][]string
+		items      string
+		pagination Pagination
+		expected   []float64
+	}{
+		{
+			name:     "link header",
+			path:     "/link",
+			expected: []float64{1, 2, 3, 4, 5},
+		},
+		{
+			name:       "next link in body",
+			path:       "/next",
+			items:      "data",
+			pagination: Pagination{Next: "links.next"},
+			expected:   []float64{1, 2, 3, 4, 5},
+		},
+		{
+			name:       "offset and limit",
+			path:       "/offset",
+			query:      map[string][]string{"limit": {"2"}},
+			items:      "data",
+			pagination: Pagination{OffsetParam: "offset", LimitParam: "limit"},
+			expected:   []float64{1, 2, 3, 4, 5},
+		},
+		{
+			name:       "max pages",
+			path:       "/link",
+			pagination: Pagination{MaxPages: 2},
+			expected:   []float64{1, 2, 3, 4},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := golium.InitializeContext(context.Background())
+			s := &Session{}
+			s.ConfigureEndpoint(ctx, server.URL)
+			s.ConfigurePath(tc.path)
+			s.ConfigureQueryParams(tc.query)
+			require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+
+			require.NoError(t, s.FollowPagination(ctx, tc.items, "users", tc.pagination))
+			var ids []float64
+			for _, item := range s.Collection {
+				ids = append(ids, item.(map[string]interface{})["id"].(float64))
+			}
+			require.Equal(t, tc.expected, ids)
+			require.Equal(t, s.Collection, golium.GetContext(ctx).Get("users"))
+			require.Equal(t, server.URL, s.Request.Endpoint)
+			require.Equal(t, tc.path, s.Request.Path)
+		})
+	}
+}
+
+func TestFollowPaginationTimeout(t *testing.T) {
+	server := newPaginatedServer()
+	defer server.Close()
+	ctx := golium.InitializeContext(context.Background())
+	s := &Session{}
+	s.ConfigureEndpoint(ctx, server.URL)
+	s.ConfigurePath("/slow")
+	require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+
+	s.SetHTTPResponseTimeout(ctx, 50)
+	err := s.FollowPagination(ctx, "", "users", Pagination{})
+	require.ErrorContains(t, err, "timeout requesting page 2")
+	require.True(t, s.Timedout)
+	require.Equal(t, "/slow", s.Request.Path)
+}
+
+func TestLinkNext(t *testing.T) {
+	tcs := []struct {
+		name     string
+		headers  []string
+		expected string
+	}{
+		{name: "no header"},
+		{name: "next", headers: []string{`<https://api/users?page=2>; rel="next"`},
+			expected: "https://api/users?page=2"},
+		{name: "several links", headers: []string{`</p1>; rel="prev", </p3>; rel=next`},
+			expected: "/p3"},
+		{name: "several relations", headers: []string{`</p1>; rel="first", </p3>; rel="last next"`},
+			expected: "/p3"},
+		{name: "other relation", headers: []string{`</p1>; rel="nextpage"`}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, LinkNext(tc.headers))
+		})
+	}
+}
+
+func TestValidateCollection(t *testing.T) {
+	ctx := context.Background()
+	s := &Session{Collection: []interface{}{
+		map[string]interface{}{"id": float64(1), "name": "alice", "group": "a"},
+		map[string]interface{}{"id": float64(2), "name": "bob", "group": "a"},
+		map[string]interface{}{"id": float64(10), "name": "carol", "group": "b"},
+	}}
+	require.NoError(t, s.ValidateCollectionCount(ctx, 3))
+	require.Error(t, s.ValidateCollectionCount(ctx, 2))
+
+	tcs := []struct {
+		name     string
+		validate func() error
+		wantErr  bool
+	}{
+		{name: "unique ids", validate: func() error { return s.ValidateCollectionUnique(ctx, "id") }},
+		{name: "unique items", validate: func() error { return s.ValidateCollectionUnique(ctx, "") }},
+		{
+			name:     "duplicated groups",
+			validate: func() error { return s.ValidateCollectionUnique(ctx, "group") },
+			wantErr:  true,
+		},
+		{
+			name:     "sorted by id",
+			validate: func() error { return s.ValidateCollectionSorted(ctx, "id", false) },
+		},
+		{
+			name:     "sorted by name",
+			validate: func() error { return s.ValidateCollectionSorted(ctx, "name", false) },
+		},
+		{
+			name:     "not sorted by id in descending order",
+			validate: func() error { return s.ValidateCollectionSorted(ctx, "id", true) },
+			wantErr:  true,
+		},
+		{
+			name:     "not comparable",
+			validate: func() error { return s.ValidateCollectionSorted(ctx, "missing", false) },
+			wantErr:  true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.validate()
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
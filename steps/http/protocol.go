@@ -0,0 +1,76 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Protocols that can be required in the HTTP client.
+const (
+	ProtocolHTTP1 = "HTTP/1.1"
+	ProtocolHTTP2 = "HTTP/2"
+	// ProtocolH2C is HTTP/2 over cleartext TCP with prior knowledge.
+	ProtocolH2C   = "h2c"
+	ProtocolHTTP3 = "HTTP/3"
+)
+
+// ConfigureProtocol requires a protocol in the HTTP client: HTTP/1.1, HTTP/2, h2c or HTTP/3.
+// An empty protocol restores the default negotiation.
+func (s *Session) ConfigureProtocol(ctx context.Context, protocol string) error {
+	switch protocol {
+	case "", ProtocolHTTP1, ProtocolHTTP2, ProtocolH2C, ProtocolHTTP3:
+		s.Protocol = protocol
+		return nil
+	}
+	return fmt.Errorf("invalid HTTP protocol '%s'", protocol)
+}
+
+// protocols returns the protocols of the HTTP transport for the configured protocol.
+func (s *Session) protocols() *http.Protocols {
+	protocols := &http.Protocols{}
+	switch s.Protocol {
+	case ProtocolHTTP1:
+		protocols.SetHTTP1(true)
+	case ProtocolHTTP2:
+		protocols.SetHTTP2(true)
+	case ProtocolH2C:
+		protocols.SetUnencryptedHTTP2(true)
+	default:
+		return nil
+	}
+	return protocols
+}
+
+// ValidateResponseProtocol validates the protocol of the HTTP response (e.g. HTTP/1.1, HTTP/2.0).
+// The minor version can be omitted for HTTP/2 and HTTP/3.
+func (s *Session) ValidateResponseProtocol(ctx context.Context, expected string) error {
+	if s.Response.HTTPResponse == nil {
+		return errors.New("no HTTP response")
+	}
+	normalized := strings.ToUpper(expected)
+	if normalized == "HTTP/2" || normalized == "HTTP/3" {
+		normalized += ".0"
+	}
+	if proto := s.Response.HTTPResponse.Proto; proto != normalized {
+		return fmt.Errorf("HTTP response protocol mismatch: expected '%s', actual '%s'",
+			expected, proto)
+	}
+	return nil
+}
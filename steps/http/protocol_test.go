@@ -0,0 +1,135 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509/pkix"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/quic-go/quic-go/http3"
+	"github.com/stretchr/testify/require"
+)
+
+var protoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Proto", r.Proto)
+	w.WriteHeader(http.StatusOK)
+})
+
+func newHTTP3Server(t *testing.T) string {
+	cert := newTestCertificate(t, pkix.Name{CommonName: "localhost"}, nil)
+	serverCert, err := tls.X509KeyPair([]byte(cert.certPEM), []byte(cert.keyPEM))
+	require.NoError(t, err)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &http3.Server{
+		Handler: protoHandler,
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			MinVersion:   tls.VersionTLS13,
+		}),
+	}
+	go server.Serve(conn)
+	t.Cleanup(func() {
+		server.Close()
+		conn.Close()
+	})
+	return fmt.Sprintf("https://%s", conn.LocalAddr())
+}
+
+func TestConfigureProtocol(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	tlsServer := httptest.NewUnstartedServer(protoHandler)
+	tlsServer.EnableHTTP2 = true
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+	h2cServer := httptest.NewUnstartedServer(protoHandler)
+	h2cServer.Config.Protocols = &http.Protocols{}
+	h2cServer.Config.Protocols.SetHTTP1(true)
+	h2cServer.Config.Protocols.SetUnencryptedHTTP2(true)
+	h2cServer.Start()
+	defer h2cServer.Close()
+	http3Endpoint := newHTTP3Server(t)
+
+	tests := []struct {
+		name          string
+		endpoint      string
+		protocol      string
+		expectedProto string
+		wantErr       bool
+	}{
+		{
+			name:          "HTTP/1.1 over TLS",
+			endpoint:      tlsServer.URL,
+			protocol:      ProtocolHTTP1,
+			expectedProto: "HTTP/1.1",
+		},
+		{
+			name:          "HTTP/2 over TLS",
+			endpoint:      tlsServer.URL,
+			protocol:      ProtocolHTTP2,
+			expectedProto: "HTTP/2",
+		},
+		{
+			name:          "HTTP/1.1 over cleartext",
+			endpoint:      h2cServer.URL,
+			protocol:      ProtocolHTTP1,
+			expectedProto: "HTTP/1.1",
+		},
+		{
+			name:          "h2c",
+			endpoint:      h2cServer.URL,
+			protocol:      ProtocolH2C,
+			expectedProto: "HTTP/2.0",
+		},
+		{
+			name:          "HTTP/3",
+			endpoint:      http3Endpoint,
+			protocol:      ProtocolHTTP3,
+			expectedProto: "HTTP/3",
+		},
+		{
+			name:     "invalid protocol",
+			protocol: "SPDY",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := &Session{}
+			s.ConfigureEndpoint(ctx, tt.endpoint)
+			s.ConfigureInsecureSkipVerify(ctx)
+			err := s.ConfigureProtocol(ctx, tt.protocol)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Session.ConfigureProtocol() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+			require.NoError(t, s.ValidateStatusCode(ctx, http.StatusOK))
+			require.NoError(t, s.ValidateResponseProtocol(ctx, tt.expectedProto))
+			require.Error(t, s.ValidateResponseProtocol(ctx, "HTTP/1.0"))
+		})
+	}
+}
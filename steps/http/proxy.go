@@ -0,0 +1,52 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+
+	"github.com/TelefonicaTC2Tech/golium/steps/http/proxy"
+)
+
+// ConfigureProxy configures the proxy of the HTTP client (http, https, socks5 or socks5h URL).
+// The username and password are optional. The no-proxy list is kept.
+func (s *Session) ConfigureProxy(ctx context.Context, proxyURL, username, password string,
+) error {
+	options := &proxy.Options{URL: proxyURL, Username: username, Password: password}
+	if s.Proxy != nil {
+		options.NoProxy = s.Proxy.NoProxy
+	}
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	s.Proxy = options
+	return nil
+}
+
+// ConfigureNoProxy configures the hosts that are accessed without proxy.
+// If there is no proxy configured yet, the other hosts keep the proxy configured with
+// environment variables.
+func (s *Session) ConfigureNoProxy(ctx context.Context, hosts []string) {
+	if s.Proxy == nil {
+		s.Proxy = &proxy.Options{}
+	}
+	s.Proxy.NoProxy = hosts
+}
+
+// DisableProxy disables the proxy of the HTTP client, even the proxy configured with
+// environment variables.
+func (s *Session) DisableProxy(ctx context.Context) {
+	s.Proxy = &proxy.Options{Disabled: true}
+}
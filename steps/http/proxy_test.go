@@ -0,0 +1,141 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newForwardProxy creates an HTTP proxy that answers the requests with the status 202
+// and the requested URL and credentials in the headers.
+func newForwardProxy(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proxied-Url", r.URL.String())
+		w.Header().Set("X-Proxy-Authorization", r.Header.Get("Proxy-Authorization"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestSendHTTPRequestWithProxy(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	proxyServer := newForwardProxy(t)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer target.Close()
+	credentials := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass"))
+
+	tests := []struct {
+		name       string
+		endpoint   string
+		username   string
+		noProxy    []string
+		insecure   bool
+		wantStatus int
+		wantAuth   string
+	}{
+		{
+			name:       "through proxy",
+			endpoint:   "http://staging.golium.test",
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "through proxy with credentials",
+			endpoint:   "http://staging.golium.test",
+			username:   "user",
+			wantStatus: http.StatusAccepted,
+			wantAuth:   credentials,
+		},
+		{
+			name:       "through proxy without TLS verification",
+			endpoint:   "http://staging.golium.test",
+			insecure:   true,
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "without proxy for the host",
+			endpoint:   target.URL,
+			noProxy:    []string{"127.0.0.1"},
+			wantStatus: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := &Session{}
+			s.ConfigureEndpoint(ctx, tt.endpoint)
+			s.ConfigurePath("/status")
+			s.InsecureSkipVerify = tt.insecure
+			password := ""
+			if tt.username != "" {
+				password = "pass"
+			}
+			require.NoError(t, s.ConfigureProxy(ctx, proxyServer.URL, tt.username, password))
+			s.ConfigureNoProxy(ctx, tt.noProxy)
+			require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+			require.NoError(t, s.ValidateStatusCode(ctx, tt.wantStatus))
+			if tt.wantStatus != http.StatusAccepted {
+				return
+			}
+			require.NoError(t, s.ValidateResponseHeaders(ctx, map[string][]string{
+				"X-Proxied-Url": {tt.endpoint + "/status"},
+			}))
+			require.Equal(t, tt.wantAuth,
+				s.Response.HTTPResponse.Header.Get("X-Proxy-Authorization"))
+		})
+	}
+}
+
+func TestConfigureNoProxyWithEnvironmentProxy(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	proxyServer := newForwardProxy(t)
+	t.Setenv("HTTP_PROXY", proxyServer.URL)
+	t.Setenv("NO_PROXY", "")
+	ctx := context.Background()
+	s := &Session{}
+	s.ConfigureNoProxy(ctx, []string{"internal.golium.test"})
+	s.ConfigureEndpoint(ctx, "http://staging.golium.test")
+	require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+	require.NoError(t, s.ValidateStatusCode(ctx, http.StatusAccepted))
+
+	s.ConfigureEndpoint(ctx, "http://internal.golium.test")
+	require.Error(t, s.SendHTTPRequest(ctx, http.MethodGet))
+}
+
+func TestConfigureProxy(t *testing.T) {
+	ctx := context.Background()
+	s := &Session{}
+	s.ConfigureNoProxy(ctx, []string{"localhost"})
+	require.False(t, s.Proxy.IsEnabled())
+	require.Error(t, s.ConfigureProxy(ctx, "ftp://proxy:21", "", ""))
+	require.NoError(t, s.ConfigureProxy(ctx, "socks5://proxy:1080", "", ""))
+	require.Equal(t, []string{"localhost"}, s.Proxy.NoProxy)
+	s.Protocol = ProtocolHTTP3
+	_, err := s.newHTTPClient(ctx)
+	require.Error(t, err)
+	s.DisableProxy(ctx)
+	require.False(t, s.Proxy.IsEnabled())
+}
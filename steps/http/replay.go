@@ -0,0 +1,134 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// CurlCommand returns a curl command line to reproduce the request with the configuration
+// of the session (TLS verification, protocol, proxy, timeout, redirections and cookies).
+// The authorization headers are obfuscated as in the logs. Multipart bodies are not included.
+func (s *Session) CurlCommand(req *http.Request, body []byte) string {
+	args := []string{"curl", "-X", req.Method, shellQuote(req.URL.String())}
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		// The Host header is ignored when sending the request in favor of req.Host
+		if name != "Host" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			args = append(args, "-H", shellQuote(name+": "+obfuscateHeader(name, value)))
+		}
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		args = append(args, "-H", shellQuote("Host: "+req.Host))
+	}
+	if s.CookieJar != nil {
+		var cookies []string
+		for _, c := range s.CookieJar.Cookies(req.URL) {
+			cookies = append(cookies, c.Name+"="+c.Value)
+		}
+		if len(cookies) > 0 {
+			args = append(args, "-b", shellQuote(strings.Join(cookies, "; ")))
+		}
+	}
+	if len(body) > 0 {
+		args = append(args, "--data-binary", shellQuote(string(body)))
+	}
+	return strings.Join(append(args, s.curlOptions()...), " ")
+}
+
+func (s *Session) curlOptions() []string {
+	var args []string
+	if s.InsecureSkipVerify {
+		args = append(args, "-k")
+	}
+	switch s.Protocol {
+	case ProtocolHTTP1:
+		args = append(args, "--http1.1")
+	case ProtocolHTTP2:
+		args = append(args, "--http2")
+	case ProtocolH2C:
+		args = append(args, "--http2-prior-knowledge")
+	case ProtocolHTTP3:
+		args = append(args, "--http3")
+	}
+	if s.Proxy.IsEnabled() {
+		if u, err := url.Parse(s.Proxy.URL); err == nil {
+			username, password := s.Proxy.Username, s.Proxy.Password
+			if username == "" && u.User != nil {
+				username = u.User.Username()
+				password, _ = u.User.Password()
+			}
+			u.User = nil
+			args = append(args, "--proxy", shellQuote(u.String()))
+			if username != "" {
+				password = GetLogger().Log.Obfuscate(password)
+				args = append(args, "--proxy-user", shellQuote(username+":"+password))
+			}
+		}
+	}
+	switch {
+	case s.Proxy != nil && s.Proxy.Disabled:
+		args = append(args, "--noproxy", shellQuote("*"))
+	case s.Proxy != nil && len(s.Proxy.NoProxy) > 0:
+		args = append(args, "--noproxy", shellQuote(strings.Join(s.Proxy.NoProxy, ",")))
+	}
+	if s.Timeout > 0 {
+		args = append(args, "--max-time", fmt.Sprint(s.Timeout.Seconds()))
+	}
+	if !s.NoRedirect {
+		args = append(args, "-L")
+	}
+	return args
+}
+
+// shellQuote quotes a string for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ResendHTTPRequest sends again the last HTTP request, overriding the headers.
+// The last request is restored in the session, discarding the configuration changed after
+// sending it.
+func (s *Session) ResendHTTPRequest(ctx context.Context, headers map[string][]string) error {
+	if s.LastRequest == nil {
+		return errors.New("no HTTP request sent")
+	}
+	request := s.LastRequest.Clone()
+	if request.Headers == nil {
+		request.Headers = make(map[string][]string)
+	}
+	for name, values := range headers {
+		for existing := range request.Headers {
+			if strings.EqualFold(existing, name) {
+				delete(request.Headers, existing)
+			}
+		}
+		request.Headers[name] = values
+	}
+	s.Request = *request
+	return s.SendHTTPRequest(ctx, request.Method)
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurlCommand(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		configure func(s *Session)
+		want      string
+	}{
+		{
+			name: "GET request",
+			configure: func(s *Session) {
+				s.ConfigurePath("/users")
+				s.ConfigureQueryParams(map[string][]string{"name": {"o'neil"}})
+			},
+			want: `curl -X GET 'http://localhost:9000/users?name=o%27neil' -L`,
+		},
+		{
+			name: "POST request with headers and body",
+			configure: func(s *Session) {
+				s.ConfigureRequestBody(ctx, `{"name": "o'neil"}`)
+				s.Request.Headers["X-Request-Id"] = []string{"1"}
+				s.Request.Headers["Host"] = []string{"golium.test"}
+				s.NoRedirect = true
+			},
+			want: `curl -X POST 'http://localhost:9000' -H 'Content-Type: application/json' ` +
+				`-H 'X-Request-Id: 1' -H 'Host: golium.test' ` +
+				`--data-binary '{"name": "o'\''neil"}'`,
+		},
+		{
+			name: "session options",
+			configure: func(s *Session) {
+				s.ConfigureInsecureSkipVerify(ctx)
+				s.ConfigureProtocol(ctx, ProtocolHTTP2)
+				s.ConfigureProxy(ctx, "http://proxy:3128", "user", "pass")
+				s.SetHTTPResponseTimeout(ctx, 1500)
+			},
+			want: `curl -X GET 'http://localhost:9000' -k --http2 --proxy 'http://proxy:3128' ` +
+				`--proxy-user 'user:pass' --max-time 1.5 -L`,
+		},
+		{
+			name: "without proxy",
+			configure: func(s *Session) {
+				s.DisableProxy(ctx)
+				s.ConfigureCredentials(ctx, "user", "pass")
+			},
+			want: `curl -X GET 'http://localhost:9000' -H 'Authorization: Basic dXNlcjpwYXNz' ` +
+				`--noproxy '*' -L`,
+		},
+		{
+			name: "with no proxy hosts",
+			configure: func(s *Session) {
+				s.ConfigureNoProxy(ctx, []string{"localhost", ".internal"})
+			},
+			want: `curl -X GET 'http://localhost:9000' --noproxy 'localhost,.internal' -L`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{}
+			s.ConfigureEndpoint(ctx, "http://localhost:9000")
+			tt.configure(s)
+			method := http.MethodGet
+			if s.Request.RequestBody != nil {
+				method = http.MethodPost
+			}
+			req, err := s.newRequest(ctx, method)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, s.CurlCommand(req, s.Request.RequestBody))
+		})
+	}
+}
+
+func TestResendHTTPRequest(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first request with an idempotency key is created and the next ones are conflicts
+		if r.Header.Get("Idempotency-Key") == "1" && atomic.AddInt32(&count, 1) > 1 {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+	ctx := context.Background()
+	s := &Session{}
+	require.Error(t, s.ResendHTTPRequest(ctx, nil))
+	s.ConfigureEndpoint(ctx, ts.URL)
+	s.ConfigureHeaders(ctx, map[string][]string{"idempotency-key": {"1"}})
+	s.ConfigureRequestBody(ctx, `{"amount": 10}`)
+	require.NoError(t, s.SendHTTPRequest(ctx, http.MethodPost))
+	require.NoError(t, s.ValidateStatusCode(ctx, http.StatusCreated))
+	// Changes after sending the request are discarded
+	s.ConfigurePath("/other")
+	require.NoError(t, s.ResendHTTPRequest(ctx, nil))
+	require.NoError(t, s.ValidateStatusCode(ctx, http.StatusConflict))
+	require.Equal(t, "", s.Request.Path)
+	require.NoError(t, s.ResendHTTPRequest(ctx, map[string][]string{"Idempotency-Key": {"2"}}))
+	require.NoError(t, s.ValidateStatusCode(ctx, http.StatusCreated))
+	require.Equal(t, map[string][]string{
+		"Idempotency-Key": {"2"},
+		"Content-Type":    {"application/json"},
+	}, s.Request.Headers)
+	require.Equal(t, http.MethodPost, s.LastRequest.Method)
+}
@@ -17,10 +17,8 @@ package http
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
-	"io"
 	"mime/multipart"
 	"net"
 	"net/http"
@@ -30,8 +28,11 @@ import (
 	"time"
 
 	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/har"
 	"github.com/TelefonicaTC2Tech/golium/steps/http/model"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/proxy"
 	"github.com/TelefonicaTC2Tech/golium/steps/http/schema"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/timing"
 	"github.com/cucumber/godog"
 	"github.com/google/uuid"
 	"github.com/tidwall/sjson"
@@ -57,6 +58,41 @@ type Session struct {
 	InsecureSkipVerify bool
 	Timeout            time.Duration
 	Timedout           bool
+	// OAuth2 is the OAuth2 profile used to authorize the requests with a bearer token.
+	OAuth2 *OAuth2Profile
+	// TLS contains the TLS settings of the HTTP client (mutual TLS, CA, versions...).
+	TLS TLSOptions
+	// CookieJar stores the cookies between requests (nil if the cookie jar is not enabled).
+	CookieJar http.CookieJar
+	// CSRF configures the extraction and injection of a CSRF token.
+	CSRF *CSRFOptions
+	// MaxBodySize is the max size of the response body in bytes (0 for no limit).
+	MaxBodySize int64
+	// BodyToFile streams the response body to a temporary file instead of keeping it in memory.
+	BodyToFile bool
+	// AcceptEncoding contains the encodings accepted by the client and decoded by golium.
+	AcceptEncoding string
+	// Stream is the HTTP stream (SSE or NDJSON) opened in the session.
+	Stream *Stream
+	// Timing of the last HTTP request.
+	Timing Timing
+	// Timings of the HTTP requests sent in the session.
+	Timings []Timing
+	// Timeouts is the number of HTTP requests sent in the session that timed out,
+	// without timing in Timings.
+	Timeouts int
+	// Load contains the results of the last HTTP load.
+	Load *LoadResult
+	// Protocol required in the HTTP client (empty for the default negotiation).
+	Protocol string
+	// Proxy configuration. If nil, the proxy is selected by the environment variables.
+	Proxy *proxy.Options
+	// LastRequest is a copy of the last HTTP request sent, to send it again.
+	LastRequest *model.Request
+	// XMLNamespaces maps the prefixes used in the XPath expressions to the XML namespaces.
+	XMLNamespaces map[string]string
+	// Collection contains the items collected from the pages of an HTTP collection.
+	Collection []interface{}
 }
 
 type RequestParams struct {
@@ -209,15 +245,61 @@ func (s *Session) SendHTTPRequest(ctx context.Context, method string) error {
 	logger := GetLogger()
 	s.Request.Method = method
 	corr := uuid.New().String()
-	u, err := s.URL()
+	req, err := s.newRequest(ctx, method)
+	if err != nil {
+		return err
+	}
+	s.Timing = Timing{}
+	// The trace is shared with the HAR recorder to get the same timing
+	trace := timing.NewTrace()
+	req = req.WithContext(timing.WithTrace(req.Context(), trace))
+	logger.LogRequest(req, s.Request.RequestBody, corr)
+	logger.LogCurl(s.CurlCommand(req, s.Request.RequestBody), corr)
+	// The request is saved before sending it because the multipart body is consumed
+	s.LastRequest = s.Request.Clone()
+	client, err := s.newHTTPClient(ctx)
 	if err != nil {
 		return err
 	}
+	defer closeHTTPClient(client)
+	resp, err := client.Do(req)
+	if err != nil {
+		if err, ok := err.(net.Error); ok && err.Timeout() {
+			logger.LogTimeout(corr)
+			logger.LogHAREntry(har.NewEntry(trace.Start, req, s.Request.RequestBody, nil, nil,
+				har.NewTimings(trace.Finish())), corr)
+			s.Timedout = true
+			s.Timeouts++
+			return nil
+		}
+		return fmt.Errorf("error with the HTTP request. %w", err)
+	}
+	defer resp.Body.Close()
+	s.Response.HTTPResponse = resp
+	if err := s.readResponseBody(resp); err != nil {
+		return err
+	}
+	s.Timing = trace.Finish()
+	s.Timings = append(s.Timings, s.Timing)
+	logger.LogResponse(resp, s.Response.ResponseBody, corr)
+	logger.LogHAREntry(har.NewEntry(trace.Start, req, s.Request.RequestBody, resp,
+		s.Response.ResponseBody, har.NewTimings(s.Timing)), corr)
+	s.extractCSRFToken()
+	return nil
+}
+
+// newRequest creates an HTTP request with the configuration of the session
+// (URL, headers, body and authorization).
+func (s *Session) newRequest(ctx context.Context, method string) (*http.Request, error) {
+	u, err := s.URL()
+	if err != nil {
+		return nil, err
+	}
 	reqBody := s.Request.GetBody()
 
 	req, err := http.NewRequest(method, u.String(), reqBody)
 	if err != nil {
-		return fmt.Errorf("failed creating the HTTP request with method '%s' and url '%s'. %w",
+		return nil, fmt.Errorf("failed creating the HTTP request with method '%s' and url '%s'. %w",
 			method, u, err)
 	}
 	if s.Request.Headers != nil {
@@ -225,43 +307,18 @@ func (s *Session) SendHTTPRequest(ctx context.Context, method string) error {
 		if found && len(hostHeaders) > 0 {
 			req.Host = hostHeaders[0]
 		}
+		// The headers are cloned because they are modified for each request (e.g. authorization).
+		req.Header = http.Header(s.Request.Headers).Clone()
 	}
-	req.Header = s.Request.Headers
 	if s.Request.Username != "" || s.Request.Password != "" {
 		req.SetBasicAuth(s.Request.Username, s.Request.Password)
 	}
-	logger.LogRequest(req, s.Request.RequestBody, corr)
-	client := http.Client{Timeout: s.Timeout}
-	if s.NoRedirect {
-		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		}
-	}
-	if s.InsecureSkipVerify { // #nosec G402
-		tlsConfig := &tls.Config{InsecureSkipVerify: true}
-		client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		if err, ok := err.(net.Error); ok && err.Timeout() {
-			logger.LogTimeout(corr)
-			s.Timedout = true
-			return nil
-		}
-		return fmt.Errorf("error with the HTTP request. %w", err)
-	}
-	defer resp.Body.Close()
-	// This is dangerous for big response bodies,
-	// but is read now to make sure that the body reader is closed.
-	// TODO: limit the max size of the response body.
-	respBodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed reading the response body: %w", err)
+	if err := s.authorizeOAuth2(ctx, req); err != nil {
+		return nil, err
 	}
-	s.Response.HTTPResponse = resp
-	s.Response.ResponseBody = respBodyBytes
-	logger.LogResponse(resp, respBodyBytes, corr)
-	return nil
+	s.applyCSRFToken(req)
+	s.applyAcceptEncoding(req)
+	return req, nil
 }
 
 // ValidateResponseTimedout checks if the HTTP client timed out without
@@ -18,8 +18,12 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/proxy"
 	"github.com/TelefonicaTC2Tech/golium/steps/http/schema"
 	"github.com/cucumber/godog"
 )
@@ -42,6 +46,14 @@ func (s Steps) InitializeSteps(ctx context.Context, scenCtx *godog.ScenarioConte
 	// Initialize the HTTP session in the context
 	ctx = InitializeContext(ctx)
 	session := GetSession(ctx)
+	scenCtx.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
+		options, err := proxy.Load(ctx)
+		if err != nil {
+			return ctx, fmt.Errorf("failed loading proxy configuration: %w", err)
+		}
+		session.Proxy = options
+		return ctx, nil
+	})
 	// Initialize the steps
 	scenCtx.Step(`^the HTTP endpoint "([^"]*)"$`, func(endpoint string) {
 		session.ConfigureEndpoint(ctx, golium.ValueAsString(ctx, endpoint))
@@ -77,6 +89,13 @@ func (s Steps) InitializeSteps(ctx context.Context, scenCtx *godog.ScenarioConte
 	scenCtx.Step(`^the HTTP request with username "([^"]*)" and password "([^"]*)"$`, func(username, password string) {
 		session.ConfigureCredentials(ctx, golium.ValueAsString(ctx, username), golium.ValueAsString(ctx, password))
 	})
+	scenCtx.Step(`^the HTTP client authenticates with OAuth2 profile "([^"]*)"$`, func(profile string) error {
+		oauth2Profile, err := LoadOAuth2Profile(ctx, golium.ValueAsString(ctx, profile))
+		if err != nil {
+			return fmt.Errorf("failed loading OAuth2 profile: %w", err)
+		}
+		return session.ConfigureOAuth2(ctx, oauth2Profile)
+	})
 	scenCtx.Step(`^the JSON properties in the HTTP request body$`, func(t *godog.Table) error {
 		props, err := golium.ConvertTableToMap(ctx, t)
 		if err != nil {
@@ -97,6 +116,15 @@ func (s Steps) InitializeSteps(ctx context.Context, scenCtx *godog.ScenarioConte
 		}
 		return session.ConfigureRequestBodyJSONFileWithout(ctx, schema.Params{File: file, Code: code}, params)
 	})
+	scenCtx.Step(`^the HTTP request body with the XML$`, func(message *godog.DocString) {
+		session.ConfigureRequestBodyXML(ctx, golium.ValueAsString(ctx, message.Content))
+	})
+	scenCtx.Step(`^the HTTP request body with the text$`, func(message *godog.DocString) {
+		session.ConfigureRequestBodyText(ctx, golium.ValueAsString(ctx, message.Content))
+	})
+	scenCtx.Step(`^the HTTP request body with the content type "([^"]*)"$`, func(contentType string, message *godog.DocString) {
+		session.ConfigureRequestBodyWithContentType(ctx, golium.ValueAsString(ctx, contentType), golium.ValueAsString(ctx, message.Content))
+	})
 	scenCtx.Step(`^the HTTP request body with the URL encoded properties$`, func(t *godog.Table) error {
 		props, err := golium.ConvertTableToMultiMap(ctx, t)
 		if err != nil {
@@ -110,8 +138,177 @@ func (s Steps) InitializeSteps(ctx context.Context, scenCtx *godog.ScenarioConte
 	scenCtx.Step(`^the HTTP client does not verify https cert$`, func() {
 		session.ConfigureInsecureSkipVerify(ctx)
 	})
+	scenCtx.Step(`^the HTTP client TLS configuration "([^"]*)"$`, func(name string) error {
+		options, err := LoadTLSOptions(ctx, golium.ValueAsString(ctx, name))
+		if err != nil {
+			return fmt.Errorf("failed loading TLS configuration: %w", err)
+		}
+		return session.ConfigureTLS(ctx, options)
+	})
+	scenCtx.Step(`^the HTTP client certificate "([^"]*)" and key "([^"]*)"$`, func(cert, key string) error {
+		return session.ConfigureClientCertificate(ctx, golium.ValueAsString(ctx, cert), golium.ValueAsString(ctx, key))
+	})
+	scenCtx.Step(`^the HTTP client CA certificates "([^"]*)"$`, func(ca string) error {
+		return session.ConfigureCA(ctx, golium.ValueAsString(ctx, ca))
+	})
+	scenCtx.Step(`^the HTTP client TLS server name "([^"]*)"$`, func(serverName string) {
+		session.ConfigureServerName(ctx, golium.ValueAsString(ctx, serverName))
+	})
+	scenCtx.Step(`^the HTTP client minimum TLS version "([^"]*)"$`, func(version string) error {
+		return session.ConfigureTLSVersions(ctx, golium.ValueAsString(ctx, version), "")
+	})
+	scenCtx.Step(`^the HTTP client maximum TLS version "([^"]*)"$`, func(version string) error {
+		return session.ConfigureTLSVersions(ctx, "", golium.ValueAsString(ctx, version))
+	})
+	scenCtx.Step(`^the HTTP client TLS cipher suites$`, func(t *godog.Table) error {
+		suites, err := golium.ConvertTableColumnToArray(ctx, t)
+		if err != nil {
+			return fmt.Errorf("failed processing cipher suites from table: %w", err)
+		}
+		return session.ConfigureCipherSuites(ctx, suites)
+	})
+	scenCtx.Step(`^the HTTP client uses the proxy "([^"]*)"$`, func(proxyURL string) error {
+		return session.ConfigureProxy(ctx, golium.ValueAsString(ctx, proxyURL), "", "")
+	})
+	scenCtx.Step(`^the HTTP client uses the proxy "([^"]*)" with the credentials "([^"]*)" and "([^"]*)"$`, func(proxyURL, username, password string) error {
+		return session.ConfigureProxy(ctx, golium.ValueAsString(ctx, proxyURL), golium.ValueAsString(ctx, username), golium.ValueAsString(ctx, password))
+	})
+	scenCtx.Step(`^the HTTP client does not use the proxy for the hosts$`, func(t *godog.Table) error {
+		hosts, err := golium.ConvertTableColumnToArray(ctx, t)
+		if err != nil {
+			return fmt.Errorf("failed processing table to a list of hosts: %w", err)
+		}
+		session.ConfigureNoProxy(ctx, hosts)
+		return nil
+	})
+	scenCtx.Step(`^the HTTP client does not use a proxy$`, func() {
+		session.DisableProxy(ctx)
+	})
+	scenCtx.Step(`^the HTTP client uses the protocol "([^"]*)"$`, func(protocol string) error {
+		return session.ConfigureProtocol(ctx, golium.ValueAsString(ctx, protocol))
+	})
+	scenCtx.Step(`^the HTTP client uses a cookie jar$`, func() error {
+		return session.EnableCookieJar(ctx)
+	})
+	scenCtx.Step(`^the HTTP client cookies$`, func(t *godog.Table) error {
+		var cookies []Cookie
+		if err := golium.ConvertTableWithHeaderToStructSlice(ctx, t, &cookies); err != nil {
+			return fmt.Errorf("failed processing cookies from table: %w", err)
+		}
+		return session.ConfigureCookies(ctx, cookies)
+	})
+	scenCtx.Step(`^the HTTP client clears the cookies$`, func() error {
+		return session.ClearCookies(ctx)
+	})
+	scenCtx.Step(`^the HTTP client sends the CSRF token from the response (header|cookie|body property|body pattern) "([^"]*)" in the request header "([^"]*)"$`, func(source, name, header string) error {
+		return session.ConfigureCSRF(ctx, source, golium.ValueAsString(ctx, name), golium.ValueAsString(ctx, header))
+	})
+	scenCtx.Step(`^the HTTP response body max size of "([^"]*)" bytes$`, func(size string) error {
+		n, err := golium.ValueAsInt(ctx, size)
+		if err != nil {
+			return fmt.Errorf("invalid max body size '%s': %w", size, err)
+		}
+		return session.ConfigureMaxBodySize(ctx, int64(n))
+	})
+	scenCtx.Step(`^the HTTP response body is stored in a file$`, func() {
+		session.ConfigureBodyFile(ctx)
+	})
+	scenCtx.Step(`^the HTTP client accepts the content encodings "([^"]*)"$`, func(encodings string) {
+		session.ConfigureAcceptEncoding(ctx, golium.ValueAsString(ctx, encodings))
+	})
 	scenCtx.Step(`^I send a HTTP "([^"]*)" request$`, func(method string) error {
-		return session.SendHTTPRequest(ctx, golium.ValueAsString(ctx, method))
+		if err := session.SendHTTPRequest(ctx, golium.ValueAsString(ctx, method)); err != nil {
+			return err
+		}
+		session.StoreTimingInContext(ctx)
+		return nil
+	})
+	scenCtx.Step(`^I send the HTTP request template "([^"]*)"$`, func(name string) error {
+		template, err := LoadRequestTemplate(ctx, golium.ValueAsString(ctx, name), nil)
+		if err != nil {
+			return err
+		}
+		return session.SendRequestTemplate(ctx, template)
+	})
+	scenCtx.Step(`^I send the HTTP request template "([^"]*)" with the properties$`, func(name string, t *godog.Table) error {
+		props, err := golium.ConvertTableToMap(ctx, t)
+		if err != nil {
+			return fmt.Errorf("failed processing table to a map for the request template: %w", err)
+		}
+		template, err := LoadRequestTemplate(ctx, golium.ValueAsString(ctx, name), props)
+		if err != nil {
+			return err
+		}
+		return session.SendRequestTemplate(ctx, template)
+	})
+	scenCtx.Step(`^I resend the last HTTP request$`, func() error {
+		return session.ResendHTTPRequest(ctx, nil)
+	})
+	scenCtx.Step(`^I resend the last HTTP request with headers$`, func(t *godog.Table) error {
+		headers, err := golium.ConvertTableToMultiMap(ctx, t)
+		if err != nil {
+			return fmt.Errorf("failed processing HTTP headers from table: %w", err)
+		}
+		return session.ResendHTTPRequest(ctx, headers)
+	})
+	scenCtx.Step(`^I send "([^"]*)" HTTP "([^"]*)" requests$`, func(count, method string) error {
+		n, err := golium.ValueAsInt(ctx, count)
+		if err != nil {
+			return fmt.Errorf("invalid number of requests '%s': %w", count, err)
+		}
+		if err := session.SendHTTPRequests(ctx, golium.ValueAsString(ctx, method), n); err != nil {
+			return err
+		}
+		session.StoreTimingInContext(ctx)
+		return nil
+	})
+	scenCtx.Step(`^I open a HTTP "([^"]*)" (SSE|NDJSON) stream$`, func(method, format string) error {
+		return session.OpenStream(ctx, golium.ValueAsString(ctx, method), format)
+	})
+	scenCtx.Step(`^I wait up to "(\d+)" seconds? (for|without) a HTTP stream event with the (type|id) "([^"]*)"$`, func(timeout int, wait, property, value string) error {
+		timeoutDuration := time.Duration(timeout) * time.Second
+		v := golium.ValueAsString(ctx, value)
+		expected := &ExpectedStreamEvent{}
+		if property == "type" {
+			expected.Type = &v
+		} else {
+			expected.ID = &v
+		}
+		return session.WaitForStreamEvent(ctx, timeoutDuration, expected, wait == "without")
+	})
+	scenCtx.Step(`^I wait up to "(\d+)" seconds? (for|without) a HTTP stream event with the text$`, func(timeout int, wait string, message *godog.DocString) error {
+		timeoutDuration := time.Duration(timeout) * time.Second
+		data := golium.ValueAsString(ctx, message.Content)
+		return session.WaitForStreamEvent(ctx, timeoutDuration, &ExpectedStreamEvent{Data: &data}, wait == "without")
+	})
+	scenCtx.Step(`^I wait up to "(\d+)" seconds? (for|without) a HTTP stream event with the JSON properties$`, func(timeout int, wait string, t *godog.Table) error {
+		timeoutDuration := time.Duration(timeout) * time.Second
+		props, err := golium.ConvertTableToMap(ctx, t)
+		if err != nil {
+			return fmt.Errorf("failed processing table to a map for the stream event: %w", err)
+		}
+		return session.WaitForStreamEventWithJSONProperties(ctx, timeoutDuration, props, wait == "without")
+	})
+	scenCtx.Step(`^the HTTP stream events must have been received in order$`, func(t *godog.Table) error {
+		var events []ExpectedStreamEvent
+		if err := golium.ConvertTableWithHeaderToStructSlice(ctx, t, &events); err != nil {
+			return fmt.Errorf("failed processing stream events from table: %w", err)
+		}
+		return session.ValidateStreamEventsOrder(ctx, events)
+	})
+	scenCtx.Step(`^I close the HTTP stream$`, func() error {
+		return session.CloseStream(ctx)
+	})
+	scenCtx.Step(`^I send "([^"]*)" HTTP "([^"]*)" requests with concurrency "([^"]*)"$`, func(count, method, concurrency string) error {
+		n, err := golium.ValueAsInt(ctx, count)
+		if err != nil {
+			return fmt.Errorf("invalid number of requests '%s': %w", count, err)
+		}
+		c, err := golium.ValueAsInt(ctx, concurrency)
+		if err != nil {
+			return fmt.Errorf("invalid concurrency '%s': %w", concurrency, err)
+		}
+		return session.SendHTTPLoad(ctx, golium.ValueAsString(ctx, method), n, c)
 	})
 	scenCtx.Step(`^the HTTP response timed out$`, func() error {
 		return session.ValidateResponseTimedout(ctx)
@@ -119,6 +316,75 @@ func (s Steps) InitializeSteps(ctx context.Context, scenCtx *godog.ScenarioConte
 	scenCtx.Step(`^the HTTP status code must be "(\d+)"$`, func(code int) error {
 		return session.ValidateStatusCode(ctx, code)
 	})
+	scenCtx.Step(`^the HTTP response protocol must be "([^"]*)"$`, func(protocol string) error {
+		return session.ValidateResponseProtocol(ctx, golium.ValueAsString(ctx, protocol))
+	})
+	scenCtx.Step(`^the HTTP response TLS version must be "([^"]*)"$`, func(version string) error {
+		return session.ValidateTLSVersion(ctx, golium.ValueAsString(ctx, version))
+	})
+	scenCtx.Step(`^the HTTP server certificate subject must be "([^"]*)"$`, func(subject string) error {
+		return session.ValidateServerCertificateSubject(ctx, golium.ValueAsString(ctx, subject))
+	})
+	scenCtx.Step(`^the HTTP server certificate must be valid for at least "([^"]*)" days$`, func(days string) error {
+		d, err := golium.ValueAsInt(ctx, days)
+		if err != nil {
+			return fmt.Errorf("invalid number of days '%s': %w", days, err)
+		}
+		return session.ValidateServerCertificateExpiry(ctx, d)
+	})
+	scenCtx.Step(`^the HTTP response (DNS |connect |TLS |first byte )?time must be less than "([^"]*)" millis$`, func(phase, millis string) error {
+		n, err := golium.ValueAsInt(ctx, millis)
+		if err != nil {
+			return fmt.Errorf("invalid response time '%s': %w", millis, err)
+		}
+		return session.ValidateResponseTime(ctx, strings.TrimSpace(phase), time.Duration(n)*time.Millisecond)
+	})
+	scenCtx.Step(`^the "([^"]*)" percentile of the HTTP response (DNS |connect |TLS |first byte )?time must be less than "([^"]*)" millis$`, func(percentile, phase, millis string) error {
+		p, err := ParsePercentile(golium.ValueAsString(ctx, percentile))
+		if err != nil {
+			return err
+		}
+		n, err := golium.ValueAsInt(ctx, millis)
+		if err != nil {
+			return fmt.Errorf("invalid response time '%s': %w", millis, err)
+		}
+		return session.ValidateResponseTimePercentile(ctx, strings.TrimSpace(phase), p, time.Duration(n)*time.Millisecond)
+	})
+	scenCtx.Step(`^the HTTP load error rate must be less than "([^"]*)" percent$`, func(rate string) error {
+		r, err := strconv.ParseFloat(golium.ValueAsString(ctx, rate), 64)
+		if err != nil {
+			return fmt.Errorf("invalid error rate '%s': %w", rate, err)
+		}
+		return session.ValidateLoadErrorRate(ctx, r)
+	})
+	scenCtx.Step(`^the HTTP load status code "(\d+)" rate must be at least "([^"]*)" percent$`, func(code int, rate string) error {
+		r, err := strconv.ParseFloat(golium.ValueAsString(ctx, rate), 64)
+		if err != nil {
+			return fmt.Errorf("invalid status code rate '%s': %w", rate, err)
+		}
+		return session.ValidateLoadStatusCodeRate(ctx, code, r)
+	})
+	scenCtx.Step(`^the HTTP load status codes must be$`, func(t *godog.Table) error {
+		var rows []struct {
+			Code  int
+			Count int
+		}
+		if err := golium.ConvertTableWithHeaderToStructSlice(ctx, t, &rows); err != nil {
+			return fmt.Errorf("failed processing status codes from table: %w", err)
+		}
+		expected := make(map[int]int, len(rows))
+		for _, row := range rows {
+			expected[row.Code] = row.Count
+		}
+		return session.ValidateLoadStatusCodes(ctx, expected)
+	})
+	scenCtx.Step(`^the HTTP load throughput must be at least "([^"]*)" requests per second$`, func(throughput string) error {
+		t, err := strconv.ParseFloat(golium.ValueAsString(ctx, throughput), 64)
+		if err != nil {
+			return fmt.Errorf("invalid throughput '%s': %w", throughput, err)
+		}
+		return session.ValidateLoadThroughput(ctx, t)
+	})
 	scenCtx.Step(`^the HTTP response must contain the headers$`, func(t *godog.Table) error {
 		headers, err := golium.ConvertTableToMultiMap(ctx, t)
 		if err != nil {
@@ -126,6 +392,35 @@ func (s Steps) InitializeSteps(ctx context.Context, scenCtx *godog.ScenarioConte
 		}
 		return session.ValidateResponseHeaders(ctx, headers)
 	})
+	scenCtx.Step(`^the HTTP response must set the cookies$`, func(t *godog.Table) error {
+		var cookies []Cookie
+		if err := golium.ConvertTableWithHeaderToStructSlice(ctx, t, &cookies); err != nil {
+			return fmt.Errorf("failed processing cookies from table: %w", err)
+		}
+		return session.ValidateResponseCookies(ctx, cookies)
+	})
+	scenCtx.Step(`^the HTTP client must have the cookie "([^"]*)" with value "([^"]*)"$`, func(name, value string) error {
+		return session.ValidateCookie(ctx, golium.ValueAsString(ctx, name), golium.ValueAsString(ctx, value))
+	})
+	scenCtx.Step(`^the HTTP client must not have the cookie "([^"]*)"$`, func(name string) error {
+		return session.ValidateNotCookie(ctx, golium.ValueAsString(ctx, name))
+	})
+	scenCtx.Step(`^the HTTP response body must have the "(md5|sha1|sha256|sha512)" checksum "([^"]*)"$`, func(algorithm, checksum string) error {
+		return session.ValidateResponseBodyChecksum(ctx, algorithm, golium.ValueAsString(ctx, checksum))
+	})
+	scenCtx.Step(`^the HTTP response (compressed |decompressed )?body size must be (less than |greater than )?"([^"]*)" bytes$`, func(kind, comparison, size string) error {
+		n, err := golium.ValueAsInt(ctx, size)
+		if err != nil {
+			return fmt.Errorf("invalid body size '%s': %w", size, err)
+		}
+		return session.ValidateResponseBodySize(ctx, kind == "compressed ", strings.TrimSpace(comparison), int64(n))
+	})
+	scenCtx.Step(`^the HTTP response content encoding must be "([^"]*)"$`, func(encoding string) error {
+		return session.ValidateResponseContentEncoding(ctx, golium.ValueAsString(ctx, encoding))
+	})
+	scenCtx.Step(`^I store the HTTP response body file path in context "([^"]*)"$`, func(ctxtKey string) error {
+		return session.StoreResponseBodyFileInContext(ctx, golium.ValueAsString(ctx, ctxtKey))
+	})
 	scenCtx.Step(`^the HTTP response must not contain the headers$`, func(t *godog.Table) error {
 		headers, err := golium.ConvertTableColumnToArray(ctx, t)
 		if err != nil {
@@ -149,6 +444,29 @@ func (s Steps) InitializeSteps(ctx context.Context, scenCtx *godog.ScenarioConte
 		}
 		return session.ValidateResponseBodyJSONProperties(ctx, props)
 	})
+	scenCtx.Step(`^the HTTP response XML namespaces$`, func(t *godog.Table) error {
+		props, err := golium.ConvertTableToMap(ctx, t)
+		if err != nil {
+			return fmt.Errorf("failed processing table to a map for the XML namespaces: %w", err)
+		}
+		namespaces := make(map[string]string, len(props))
+		for prefix, namespace := range props {
+			namespaces[prefix] = fmt.Sprint(namespace)
+		}
+		session.ConfigureXMLNamespaces(ctx, namespaces)
+		return nil
+	})
+	scenCtx.Step(`^the HTTP response XML must have the properties$`, func(t *godog.Table) error {
+		props, err := golium.ConvertTableToMap(ctx, t)
+		if err != nil {
+			return fmt.Errorf("failed processing table to a map for the XML properties: %w", err)
+		}
+		return session.ValidateResponseBodyXMLProperties(ctx, props)
+	})
+	initializeXMLSchemaSteps(ctx, scenCtx, session)
+	scenCtx.Step(`^I store the element "([^"]*)" from the XML HTTP response body in context "([^"]*)"$`, func(expr, ctxtKey string) error {
+		return session.StoreResponseBodyXMLPropertyInContext(ctx, golium.ValueAsString(ctx, expr), golium.ValueAsString(ctx, ctxtKey))
+	})
 	scenCtx.Step(`^the HTTP response body must be empty$`, func() error {
 		return session.ValidateResponseBodyEmpty(ctx)
 	})
@@ -280,5 +598,32 @@ func (s Steps) InitializeSteps(ctx context.Context, scenCtx *godog.ScenarioConte
 		func(response, code string, t *godog.Table) error {
 			return session.ValidateResponseBodyJSONFileModifying(ctx, schema.Params{File: response, Code: code}, t)
 		})
+	scenCtx.Step(`^I follow the HTTP pagination collecting "([^"]*)" in context "([^"]*)"$`, func(items, ctxtKey string) error {
+		return session.FollowPagination(ctx, golium.ValueAsString(ctx, items), golium.ValueAsString(ctx, ctxtKey), Pagination{})
+	})
+	scenCtx.Step(`^I follow the HTTP pagination collecting "([^"]*)" in context "([^"]*)" with the options$`, func(items, ctxtKey string, t *godog.Table) error {
+		var pagination Pagination
+		if err := golium.ConvertTableWithoutHeaderToStruct(ctx, t, &pagination); err != nil {
+			return fmt.Errorf("failed processing table to the pagination options: %w", err)
+		}
+		return session.FollowPagination(ctx, golium.ValueAsString(ctx, items), golium.ValueAsString(ctx, ctxtKey), pagination)
+	})
+	scenCtx.Step(`^the HTTP collection must have "([^"]*)" items$`, func(count string) error {
+		n, err := golium.ValueAsInt(ctx, count)
+		if err != nil {
+			return fmt.Errorf("invalid number of items '%s': %w", count, err)
+		}
+		return session.ValidateCollectionCount(ctx, n)
+	})
+	scenCtx.Step(`^the HTTP collection must have unique values of "([^"]*)"$`, func(property string) error {
+		return session.ValidateCollectionUnique(ctx, golium.ValueAsString(ctx, property))
+	})
+	scenCtx.Step(`^the HTTP collection must be sorted by "([^"]*)"( in descending order)?$`, func(property, descending string) error {
+		return session.ValidateCollectionSorted(ctx, golium.ValueAsString(ctx, property), descending != "")
+	})
+	scenCtx.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		session.RemoveBodyFile()
+		return ctx, session.CloseStream(ctx)
+	})
 	return ctx
 }
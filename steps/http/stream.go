@@ -0,0 +1,360 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Formats of the HTTP streams.
+const (
+	StreamFormatSSE    = "SSE"
+	StreamFormatNDJSON = "NDJSON"
+
+	sseDefaultEventType = "message"
+)
+
+var streamAcceptHeaders = map[string]string{
+	StreamFormatSSE:    "text/event-stream",
+	StreamFormatNDJSON: "application/x-ndjson",
+}
+
+// StreamEvent is an event received in an HTTP stream: a Server-Sent Event or a NDJSON line.
+// NDJSON events only have data.
+type StreamEvent struct {
+	ID   string
+	Type string
+	Data string
+
+	consumed bool
+}
+
+// ExpectedStreamEvent is a row of a table of stream events. The properties are nil when
+// the column is not included in the table, so that they are not considered in the matching.
+type ExpectedStreamEvent struct {
+	ID   *string
+	Type *string
+	Data *string
+}
+
+// Stream is an HTTP stream whose events are received in background.
+type Stream struct {
+	Format string
+	// Events received from the stream, in order
+	Events []*StreamEvent
+
+	corr   string
+	client *http.Client
+	body   io.ReadCloser
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+	mutex  sync.Mutex
+}
+
+// OpenStream sends an HTTP request and receives the events of the response body in background.
+// The format of the stream is SSE or NDJSON.
+func (s *Session) OpenStream(ctx context.Context, method, format string) error {
+	accept, ok := streamAcceptHeaders[format]
+	if !ok {
+		return fmt.Errorf("invalid HTTP stream format '%s'", format)
+	}
+	if err := s.CloseStream(ctx); err != nil {
+		return err
+	}
+	logger := GetLogger()
+	s.Request.Method = method
+	corr := uuid.New().String()
+	req, err := s.newRequest(ctx, method)
+	if err != nil {
+		return err
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", accept)
+	}
+	streamCtx, cancel := context.WithCancel(context.Background())
+	req = req.WithContext(streamCtx)
+	logger.LogRequest(req, s.Request.RequestBody, corr)
+	client, err := s.newHTTPClient(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	// The timeout of the client would close the stream.
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		cancel()
+		closeHTTPClient(client)
+		return fmt.Errorf("error opening the HTTP stream. %w", err)
+	}
+	s.Response.HTTPResponse = resp
+	s.Response.ResponseBody = nil
+	logger.LogResponse(resp, nil, corr)
+	s.Stream = &Stream{
+		Format: format,
+		corr:   corr,
+		client: client,
+		body:   resp.Body,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go s.Stream.receive()
+	return nil
+}
+
+// CloseStream closes the HTTP stream (if opened) and waits until the background
+// reception is finished.
+func (s *Session) CloseStream(ctx context.Context) error {
+	if s.Stream == nil || s.Stream.cancel == nil {
+		return nil
+	}
+	s.Stream.cancel()
+	err := s.Stream.body.Close()
+	<-s.Stream.done
+	closeHTTPClient(s.Stream.client)
+	s.Stream.cancel = nil
+	return err
+}
+
+func (st *Stream) receive() {
+	defer close(st.done)
+	logrus.Debugf("Receiving events from HTTP stream %s...", st.corr)
+	reader := bufio.NewReader(st.body)
+	var err error
+	if st.Format == StreamFormatSSE {
+		err = st.receiveSSE(reader)
+	} else {
+		err = st.receiveNDJSON(reader)
+	}
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, context.Canceled) {
+		st.mutex.Lock()
+		st.err = err
+		st.mutex.Unlock()
+	}
+	logrus.Debugf("Stop receiving events from HTTP stream %s", st.corr)
+}
+
+// receiveSSE parses the Server-Sent Events following the event stream interpretation
+// of the HTML specification.
+func (st *Stream) receiveSSE(reader *bufio.Reader) error {
+	var lastID string
+	var eventType string
+	var data []string
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			return err
+		}
+		if line == "" {
+			if len(data) > 0 {
+				if eventType == "" {
+					eventType = sseDefaultEventType
+				}
+				st.add(&StreamEvent{ID: lastID, Type: eventType, Data: strings.Join(data, "\n")})
+			}
+			eventType, data = "", nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			lastID = value
+		}
+	}
+}
+
+func (st *Stream) receiveNDJSON(reader *bufio.Reader) error {
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) != "" {
+			st.add(&StreamEvent{Data: line})
+		}
+	}
+}
+
+// readLine reads a line without the end of line (LF or CRLF).
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+func (st *Stream) add(event *StreamEvent) {
+	GetLogger().LogStreamEvent(event, st.corr)
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	st.Events = append(st.Events, event)
+}
+
+// consume finds the first event, not consumed yet, that matches.
+// The event is marked as consumed so that it is not matched again.
+func (st *Stream) consume(match func(event *StreamEvent) bool) *StreamEvent {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	for _, event := range st.Events {
+		if !event.consumed && match(event) {
+			event.consumed = true
+			return event
+		}
+	}
+	return nil
+}
+
+func (st *Stream) receiveError() error {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	return st.err
+}
+
+// WaitForStreamEvent waits up to timeout until an event matching the expected event
+// is received in the HTTP stream.
+// When wantErr is set to true, it returns error if the event is received, and no error
+// if the event is not received after the timeout.
+func (s *Session) WaitForStreamEvent(ctx context.Context, timeout time.Duration,
+	expected *ExpectedStreamEvent, wantErr bool,
+) error {
+	return s.waitForStreamEvent(timeout, wantErr, func(event *StreamEvent) bool {
+		return expected.match(event)
+	}, fmt.Sprintf("HTTP stream event %s", expected))
+}
+
+// WaitForStreamEventWithJSONProperties waits up to timeout until an event with the JSON
+// properties in its data is received in the HTTP stream.
+// When wantErr is set to true, it returns error if the event is received, and no error
+// if the event is not received after the timeout.
+func (s *Session) WaitForStreamEventWithJSONProperties(ctx context.Context,
+	timeout time.Duration, props map[string]interface{}, wantErr bool,
+) error {
+	return s.waitForStreamEvent(timeout, wantErr, func(event *StreamEvent) bool {
+		return matchJSONProperties(event.Data, props)
+	}, fmt.Sprintf("HTTP stream event with JSON properties '%+v'", props))
+}
+
+func (s *Session) waitForStreamEvent(timeout time.Duration, wantErr bool,
+	match func(event *StreamEvent) bool, description string,
+) error {
+	if s.Stream == nil {
+		return errors.New("no HTTP stream opened")
+	}
+	err := waitUpTo(timeout, func() error {
+		if s.Stream.consume(match) != nil {
+			return nil
+		}
+		if streamErr := s.Stream.receiveError(); streamErr != nil {
+			return fmt.Errorf("not received %s: %w", description, streamErr)
+		}
+		return fmt.Errorf("not received %s", description)
+	})
+	if wantErr {
+		if err == nil {
+			return fmt.Errorf("received %s", description)
+		}
+		return nil
+	}
+	return err
+}
+
+// ValidateStreamEventsOrder validates that the expected events have been received
+// in the same order. Other events may be received between the expected ones.
+func (s *Session) ValidateStreamEventsOrder(ctx context.Context,
+	expectedEvents []ExpectedStreamEvent,
+) error {
+	if s.Stream == nil {
+		return errors.New("no HTTP stream opened")
+	}
+	s.Stream.mutex.Lock()
+	defer s.Stream.mutex.Unlock()
+	i := 0
+	for _, event := range s.Stream.Events {
+		if i < len(expectedEvents) && expectedEvents[i].match(event) {
+			i++
+		}
+	}
+	if i < len(expectedEvents) {
+		return fmt.Errorf("not received %s in order after %d events",
+			&expectedEvents[i], len(s.Stream.Events))
+	}
+	return nil
+}
+
+func (e *ExpectedStreamEvent) match(event *StreamEvent) bool {
+	return (e.ID == nil || *e.ID == event.ID) &&
+		(e.Type == nil || *e.Type == event.Type) &&
+		(e.Data == nil || *e.Data == event.Data)
+}
+
+func (e *ExpectedStreamEvent) String() string {
+	var props []string
+	if e.ID != nil {
+		props = append(props, fmt.Sprintf("id '%s'", *e.ID))
+	}
+	if e.Type != nil {
+		props = append(props, fmt.Sprintf("type '%s'", *e.Type))
+	}
+	if e.Data != nil {
+		props = append(props, fmt.Sprintf("data '%s'", *e.Data))
+	}
+	return fmt.Sprintf("with %s", strings.Join(props, " and "))
+}
+
+func matchJSONProperties(msg string, expectedProps map[string]interface{}) bool {
+	m := golium.NewMapFromJSONBytes([]byte(msg))
+	for key, expectedValue := range expectedProps {
+		value := m.Get(key)
+		if value != expectedValue {
+			logrus.Debugf("Invalid value: %+v. Expected: %+v", value, expectedValue)
+			return false
+		}
+	}
+	return true
+}
+
+// waitUpTo invokes f, every 10 milliseconds, until it does not return error or the timeout expires.
+func waitUpTo(timeout time.Duration, f func() error) error {
+	end := time.Now().Add(timeout)
+	for {
+		err := f()
+		if err == nil || !time.Now().Before(end) {
+			return err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sseStream = ": comment\n" +
+	"id: 1\nevent: created\ndata: {\"user\": \"alice\",\n" +
+	"data: \"status\": \"created\"}\n\n" +
+	"id: 2\r\nevent: updated\r\ndata: {\"user\": \"alice\", \"status\": \"updated\"}\r\n\r\n" +
+	"data: ping\n\n"
+
+const ndjsonStream = "{\"user\": \"alice\", \"status\": \"created\"}\n" +
+	"\n" +
+	"{\"user\": \"alice\", \"status\": \"updated\"}\n"
+
+// newStreamServer writes the stream after a delay and keeps the connection open
+// until the client closes it.
+func newStreamServer(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Accept", r.Header.Get("Accept"))
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond)
+		switch r.URL.Path {
+		case "/sse":
+			fmt.Fprint(w, sseStream)
+		case "/ndjson":
+			fmt.Fprint(w, ndjsonStream)
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestSSEStream(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := newStreamServer(t)
+	ctx := context.Background()
+	s := &Session{}
+	s.ConfigureEndpoint(ctx, ts.URL)
+	s.ConfigurePath("/sse")
+	s.SetHTTPResponseTimeout(ctx, 10)
+	require.NoError(t, s.OpenStream(ctx, http.MethodGet, StreamFormatSSE))
+	defer s.CloseStream(ctx)
+	require.NoError(t, s.ValidateStatusCode(ctx, http.StatusOK))
+	require.NoError(t, s.ValidateResponseHeaders(ctx, map[string][]string{
+		"X-Accept": {"text/event-stream"},
+	}))
+
+	require.NoError(t, s.WaitForStreamEvent(ctx, time.Second,
+		&ExpectedStreamEvent{Type: stringPtr("updated")}, false))
+	require.NoError(t, s.WaitForStreamEventWithJSONProperties(ctx, time.Second,
+		map[string]interface{}{"user": "alice", "status": "created"}, false))
+	// Events are consumed and cannot be matched again
+	require.Error(t, s.WaitForStreamEvent(ctx, 50*time.Millisecond,
+		&ExpectedStreamEvent{ID: stringPtr("1")}, false))
+	require.NoError(t, s.WaitForStreamEvent(ctx, time.Second,
+		&ExpectedStreamEvent{
+			Type: stringPtr("message"), ID: stringPtr("2"), Data: stringPtr("ping"),
+		}, false))
+	require.NoError(t, s.WaitForStreamEvent(ctx, 50*time.Millisecond,
+		&ExpectedStreamEvent{Type: stringPtr("deleted")}, true))
+
+	require.NoError(t, s.ValidateStreamEventsOrder(ctx, []ExpectedStreamEvent{
+		{Type: stringPtr("created")},
+		{ID: stringPtr("2")},
+	}))
+	require.Error(t, s.ValidateStreamEventsOrder(ctx, []ExpectedStreamEvent{
+		{Type: stringPtr("updated")},
+		{Type: stringPtr("created")},
+	}))
+	require.NoError(t, s.CloseStream(ctx))
+	require.NoError(t, s.CloseStream(ctx))
+}
+
+func TestNDJSONStream(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := newStreamServer(t)
+	ctx := context.Background()
+	s := &Session{}
+	s.ConfigureEndpoint(ctx, ts.URL)
+	s.ConfigurePath("/ndjson")
+	require.NoError(t, s.OpenStream(ctx, http.MethodGet, StreamFormatNDJSON))
+	defer s.CloseStream(ctx)
+
+	require.NoError(t, s.WaitForStreamEventWithJSONProperties(ctx, time.Second,
+		map[string]interface{}{"status": "updated"}, false))
+	require.NoError(t, s.WaitForStreamEventWithJSONProperties(ctx, 50*time.Millisecond,
+		map[string]interface{}{"status": "deleted"}, true))
+	require.NoError(t, s.ValidateStreamEventsOrder(ctx, []ExpectedStreamEvent{
+		{Data: stringPtr(`{"user": "alice", "status": "created"}`)},
+		{Data: stringPtr(`{"user": "alice", "status": "updated"}`)},
+	}))
+	require.Len(t, s.Stream.Events, 2)
+}
+
+func TestStreamErrors(t *testing.T) {
+	ctx := context.Background()
+	s := &Session{}
+	require.Error(t, s.OpenStream(ctx, http.MethodGet, "XML"))
+	require.Error(t, s.WaitForStreamEvent(ctx, 0, &ExpectedStreamEvent{}, false))
+	require.Error(t, s.ValidateStreamEventsOrder(ctx, nil))
+	require.NoError(t, s.CloseStream(ctx))
+}
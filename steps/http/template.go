@@ -0,0 +1,238 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/model"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/schema"
+	"github.com/tidwall/sjson"
+	"gopkg.in/yaml.v3"
+)
+
+// templateExtensions are the file extensions of the request templates, in order of preference.
+var templateExtensions = []string{".yml", ".yaml", ".json"}
+
+// RequestTemplate is a template of an HTTP request and its expected response.
+// It is loaded from a yml or json file:
+//
+//	request:
+//	  method: POST
+//	  endpoint: "[CONF:url]"
+//	  path: /users
+//	  query:
+//	    tags: [admin, staff]
+//	  headers:
+//	    Authorization: Bearer [CTXT:token]
+//	  body:
+//	    name: alice
+//	response:
+//	  status: 201
+//	  headers:
+//	    Content-Type: application/json
+//	  json:
+//	    name: alice
+//	  schema: user
+type RequestTemplate struct {
+	Request  TemplateRequest   `json:"request"`
+	Response *TemplateResponse `json:"response"`
+}
+
+// TemplateRequest is the request of a RequestTemplate.
+// The endpoint is optional (the endpoint of the session is used).
+// The body is sent as text if it is a string, or as JSON otherwise.
+type TemplateRequest struct {
+	Method   string                 `json:"method"`
+	Endpoint string                 `json:"endpoint"`
+	Path     string                 `json:"path"`
+	Query    map[string]interface{} `json:"query"`
+	Headers  map[string]interface{} `json:"headers"`
+	Body     interface{}            `json:"body"`
+}
+
+// TemplateResponse is the expected response of a RequestTemplate.
+// The body is compared as text if it is a string, or as JSON otherwise.
+// JSON contains the expected JSON properties of the body, and Schema the name of the
+// JSON schema to validate the body.
+type TemplateResponse struct {
+	Status  int                    `json:"status"`
+	Headers map[string]interface{} `json:"headers"`
+	Body    interface{}            `json:"body"`
+	JSON    map[string]interface{} `json:"json"`
+	Schema  string                 `json:"schema"`
+}
+
+// LoadRequestTemplate loads the request template from the file {name}.yml, {name}.yaml or
+// {name}.json in the templates directory. The golium tags of the template are resolved, and
+// then the properties override the values of the template (e.g. "request.body.name").
+func LoadRequestTemplate(ctx context.Context, name string, props map[string]interface{},
+) (*RequestTemplate, error) {
+	data, err := readTemplateFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed parsing HTTP request template '%s': %w", name, err)
+	}
+	b, err := json.Marshal(resolveTemplateValues(ctx, raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed converting HTTP request template '%s': %w", name, err)
+	}
+	for key, value := range props {
+		if b, err = sjson.SetBytes(b, key, value); err != nil {
+			return nil, fmt.Errorf("failed setting property '%s' in HTTP request template '%s': %w",
+				key, name, err)
+		}
+	}
+	var template RequestTemplate
+	if err := json.Unmarshal(b, &template); err != nil {
+		return nil, fmt.Errorf("invalid HTTP request template '%s': %w", name, err)
+	}
+	return &template, nil
+}
+
+func readTemplateFile(name string) ([]byte, error) {
+	dir := golium.GetConfig().Dir.Templates
+	for _, ext := range templateExtensions {
+		data, err := os.ReadFile(filepath.Join(dir, name+ext))
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed reading HTTP request template '%s': %w", name, err)
+		}
+	}
+	return nil, fmt.Errorf("HTTP request template '%s' not found in '%s'", name, dir)
+}
+
+// resolveTemplateValues resolves the golium tags of the string values.
+func resolveTemplateValues(ctx context.Context, v interface{}) interface{} {
+	switch value := v.(type) {
+	case string:
+		return golium.Value(ctx, value)
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = resolveTemplateValues(ctx, item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = resolveTemplateValues(ctx, item)
+		}
+	}
+	return v
+}
+
+// templateMultiValues converts the values of a template (a value or a list of values)
+// into a multimap, as used in query params and headers.
+func templateMultiValues(values map[string]interface{}) map[string][]string {
+	m := make(map[string][]string, len(values))
+	for key, value := range values {
+		if list, ok := value.([]interface{}); ok {
+			for _, item := range list {
+				m[key] = append(m[key], fmt.Sprint(item))
+			}
+			continue
+		}
+		m[key] = []string{fmt.Sprint(value)}
+	}
+	return m
+}
+
+// SendRequestTemplate sends the request of the template and validates the response of the
+// template. The request is built only from the template, except the endpoint that defaults to
+// the endpoint of the session, and the request of the session is restored after sending it.
+func (s *Session) SendRequestTemplate(ctx context.Context, template *RequestTemplate) error {
+	sessionRequest := s.Request
+	defer func() { s.Request = sessionRequest }()
+	request := template.Request
+	s.Request = model.Request{Endpoint: sessionRequest.Endpoint}
+	if request.Endpoint != "" {
+		s.ConfigureEndpoint(ctx, request.Endpoint)
+	}
+	if request.Path != "" {
+		s.ConfigurePath(request.Path)
+	}
+	if request.Query != nil {
+		s.ConfigureQueryParams(templateMultiValues(request.Query))
+	}
+	s.Request.Headers = make(map[string][]string)
+	if request.Body != nil {
+		s.Request.AddBody(request.Body)
+		if _, ok := request.Body.(string); !ok {
+			s.Request.AddJSONHeaders()
+		}
+	}
+	for key, values := range templateMultiValues(request.Headers) {
+		s.Request.Headers[http.CanonicalHeaderKey(key)] = values
+	}
+	method := request.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	if err := s.SendHTTPRequest(ctx, method); err != nil {
+		return err
+	}
+	if template.Response == nil {
+		return nil
+	}
+	return s.validateTemplateResponse(ctx, template.Response)
+}
+
+func (s *Session) validateTemplateResponse(ctx context.Context, expected *TemplateResponse,
+) error {
+	if s.Response.HTTPResponse == nil {
+		return errors.New("no HTTP response")
+	}
+	if expected.Status != 0 {
+		if err := s.ValidateStatusCode(ctx, expected.Status); err != nil {
+			return err
+		}
+	}
+	if err := s.ValidateResponseHeaders(ctx, templateMultiValues(expected.Headers)); err != nil {
+		return err
+	}
+	switch body := expected.Body.(type) {
+	case nil:
+	case string:
+		if err := s.ValidateResponseBodyText(ctx, body); err != nil {
+			return err
+		}
+	default:
+		var actual interface{}
+		if err := json.Unmarshal(s.Response.ResponseBody, &actual); err != nil {
+			return fmt.Errorf("failed unmarshalling the HTTP response body: %w", err)
+		}
+		if !schema.JSONEquals(body, actual) {
+			return fmt.Errorf("HTTP response body mismatch: expected '%v', actual '%s'",
+				body, s.Response.ResponseBody)
+		}
+	}
+	if err := s.ValidateResponseBodyJSONProperties(ctx, expected.JSON); err != nil {
+		return err
+	}
+	if expected.Schema != "" {
+		return s.ValidateResponseBodyJSONSchema(ctx, expected.Schema)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/stretchr/testify/require"
+)
+
+const userTemplate = `
+request:
+  method: POST
+  path: /users
+  query:
+    tags: [admin, staff]
+  headers:
+    X-Request-Id: "[SHA256:alice]"
+  body:
+    name: alice
+    age: "[NUMBER:30]"
+    admin: "[TRUE]"
+response:
+  status: 201
+  headers:
+    Content-Type: application/json
+  json:
+    name: alice
+    age: 30
+    admin: true
+`
+
+const statusTemplate = `{
+  "request": {"path": "/status"},
+  "response": {"status": 200, "body": {"status": "ok"}}
+}`
+
+// newEchoServer answers with the request JSON body, adding the query params and the headers.
+func newEchoServer(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/status" {
+			w.Write([]byte(`{"status": "ok"}`))
+			return
+		}
+		body := map[string]interface{}{}
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+		body["tags"] = r.URL.Query()["tags"]
+		body["requestId"] = r.Header.Get("X-Request-Id")
+		body["contentType"] = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestSendRequestTemplate(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "users"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users", "create.yml"),
+		[]byte(userTemplate), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "status.json"),
+		[]byte(statusTemplate), os.ModePerm))
+	config := golium.GetConfig()
+	templatesDir := config.Dir.Templates
+	config.Dir.Templates = dir
+	defer func() { config.Dir.Templates = templatesDir }()
+	ts := newEchoServer(t)
+
+	tests := []struct {
+		name     string
+		template string
+		props    map[string]interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "yml template",
+			template: "users/create",
+		},
+		{
+			name:     "json template",
+			template: "status",
+		},
+		{
+			name:     "template with properties",
+			template: "users/create",
+			props: map[string]interface{}{
+				"request.body.name":  "bob",
+				"response.json.name": "bob",
+			},
+		},
+		{
+			name:     "unexpected response",
+			template: "users/create",
+			props:    map[string]interface{}{"response.json.name": "bob"},
+			wantErr:  true,
+		},
+		{
+			name:     "unexpected status code",
+			template: "status",
+			props:    map[string]interface{}{"response.status": 204},
+			wantErr:  true,
+		},
+		{
+			name:     "template not found",
+			template: "users/delete",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := &Session{}
+			s.ConfigureEndpoint(ctx, ts.URL)
+			template, err := LoadRequestTemplate(ctx, tt.template, tt.props)
+			if err == nil {
+				err = s.SendRequestTemplate(ctx, template)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Session.SendRequestTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSendRequestTemplateRequest(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "create.yaml"),
+		[]byte(userTemplate), os.ModePerm))
+	config := golium.GetConfig()
+	templatesDir := config.Dir.Templates
+	config.Dir.Templates = dir
+	defer func() { config.Dir.Templates = templatesDir }()
+	ts := newEchoServer(t)
+
+	ctx := context.Background()
+	s := &Session{}
+	s.ConfigureEndpoint(ctx, ts.URL)
+	template, err := LoadRequestTemplate(ctx, "create", nil)
+	require.NoError(t, err)
+	require.NoError(t, s.SendRequestTemplate(ctx, template))
+	require.NoError(t, s.ValidateResponseBodyJSONProperties(ctx, map[string]interface{}{
+		"tags.#":      float64(2),
+		"tags.1":      "staff",
+		"contentType": "application/json",
+		"requestId":   "2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90",
+	}))
+}
+
+func TestSendRequestTemplateIsolation(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "echo.yml"),
+		[]byte("request:\n  method: POST\n  path: /echo\n"), os.ModePerm))
+	config := golium.GetConfig()
+	templatesDir := config.Dir.Templates
+	config.Dir.Templates = dir
+	defer func() { config.Dir.Templates = templatesDir }()
+	ts := newEchoServer(t)
+
+	ctx := context.Background()
+	s := &Session{}
+	s.ConfigureEndpoint(ctx, ts.URL)
+	s.ConfigurePath("/previous")
+	s.ConfigureQueryParams(map[string][]string{"tags": {"previous"}})
+	s.ConfigureHeaders(ctx, map[string][]string{"X-Request-Id": {"previous"}})
+	s.Request.AddBody(map[string]interface{}{"leak": true})
+	previous := s.Request
+
+	template, err := LoadRequestTemplate(ctx, "echo", nil)
+	require.NoError(t, err)
+	require.NoError(t, s.SendRequestTemplate(ctx, template))
+	require.Equal(t, "/echo", s.LastRequest.Path)
+	require.NoError(t, s.ValidateResponseBodyJSONProperties(ctx, map[string]interface{}{
+		"requestId": "",
+		"tags":      nil,
+		"leak":      nil,
+	}))
+	require.Equal(t, previous, s.Request)
+}
@@ -0,0 +1,158 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/timing"
+)
+
+// Phases of the timing of an HTTP request.
+const (
+	TimingDNS       = timing.DNS
+	TimingConnect   = timing.Connect
+	TimingTLS       = timing.TLS
+	TimingFirstByte = timing.FirstByte
+	TimingTotal     = timing.Total
+
+	ctxtTimingPrefix = "http.timing."
+)
+
+// Timing contains the duration of the phases of an HTTP request.
+type Timing = timing.Timing
+
+// Percentile returns the percentile (0-100) of the durations with the nearest-rank method.
+func Percentile(durations []time.Duration, percentile float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// ParsePercentile parses a percentile like "95", "p95" or "99.9".
+func ParsePercentile(percentile string) (float64, error) {
+	p, err := strconv.ParseFloat(strings.TrimPrefix(strings.ToLower(percentile), "p"), 64)
+	if err != nil || p <= 0 || p > 100 {
+		return 0, fmt.Errorf("invalid percentile '%s'", percentile)
+	}
+	return p, nil
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// StoreTimingInContext stores the timing of the last HTTP request, in milliseconds, in the
+// context with the keys: http.timing.dns, http.timing.connect, http.timing.tls,
+// http.timing.firstbyte and http.timing.total.
+func (s *Session) StoreTimingInContext(ctx context.Context) {
+	c := golium.GetContext(ctx)
+	c.Put(ctxtTimingPrefix+"dns", durationMillis(s.Timing.DNS))
+	c.Put(ctxtTimingPrefix+"connect", durationMillis(s.Timing.Connect))
+	c.Put(ctxtTimingPrefix+"tls", durationMillis(s.Timing.TLS))
+	c.Put(ctxtTimingPrefix+"firstbyte", durationMillis(s.Timing.FirstByte))
+	c.Put(ctxtTimingPrefix+"total", durationMillis(s.Timing.Total))
+}
+
+// SendHTTPRequests sends the same HTTP request sequentially the number of times.
+// The timings of the previous requests are discarded, so that the percentiles
+// are calculated only with these requests.
+func (s *Session) SendHTTPRequests(ctx context.Context, method string, count int) error {
+	if count < 1 {
+		return fmt.Errorf("invalid number of HTTP requests '%d'", count)
+	}
+	s.Timings = nil
+	s.Timeouts = 0
+	for i := 0; i < count; i++ {
+		if err := s.SendHTTPRequest(ctx, method); err != nil {
+			return fmt.Errorf("failed sending HTTP request %d of %d: %w", i+1, count, err)
+		}
+	}
+	return nil
+}
+
+// ValidateResponseTime validates that the duration of a phase (empty for the total duration)
+// of the last HTTP request (or the last request of an HTTP load) is less than the maximum.
+func (s *Session) ValidateResponseTime(ctx context.Context, phase string,
+	maxDuration time.Duration,
+) error {
+	if s.Timing == (Timing{}) {
+		return errors.New("no HTTP request timing")
+	}
+	d, err := s.Timing.Phase(phase)
+	if err != nil {
+		return err
+	}
+	if d >= maxDuration {
+		return fmt.Errorf("HTTP response %s time '%s' is not less than '%s'",
+			phaseName(phase), d, maxDuration)
+	}
+	return nil
+}
+
+// ValidateResponseTimePercentile validates that the percentile of the duration of a phase
+// (empty for the total duration) of the HTTP requests sent in the session is less than
+// the maximum. It fails if any request timed out, because its duration is unknown.
+func (s *Session) ValidateResponseTimePercentile(ctx context.Context, phase string,
+	percentile float64, maxDuration time.Duration,
+) error {
+	if s.Timeouts > 0 {
+		return fmt.Errorf("%d of %d HTTP requests timed out", s.Timeouts,
+			s.Timeouts+len(s.Timings))
+	}
+	if len(s.Timings) == 0 {
+		return errors.New("no HTTP request timing")
+	}
+	durations := make([]time.Duration, 0, len(s.Timings))
+	for _, timing := range s.Timings {
+		d, err := timing.Phase(phase)
+		if err != nil {
+			return err
+		}
+		durations = append(durations, d)
+	}
+	d := Percentile(durations, percentile)
+	if d >= maxDuration {
+		return fmt.Errorf("percentile %v of the HTTP response %s time '%s' is not less than '%s' "+
+			"with %d requests", percentile, phaseName(phase), d, maxDuration, len(durations))
+	}
+	return nil
+}
+
+func phaseName(phase string) string {
+	if phase == "" {
+		return TimingTotal
+	}
+	return phase
+}
@@ -0,0 +1,164 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package timing measures the duration of the phases of HTTP requests with httptrace.
+// It is shared by the HTTP steps and the HAR recorder.
+package timing
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Phases of the timing of an HTTP request.
+const (
+	DNS       = "DNS"
+	Connect   = "connect"
+	TLS       = "TLS"
+	FirstByte = "first byte"
+	Total     = "total"
+)
+
+// Timing contains the duration of the phases of an HTTP request.
+// DNS, Connect and TLS are zero when a connection is reused.
+type Timing struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// FirstByte is the time to the first byte of the response since the request started.
+	FirstByte time.Duration
+	// Total is the time since the request started until the response body is read.
+	Total time.Duration
+}
+
+// Phase returns the duration of a phase (DNS, connect, TLS, first byte or total).
+func (t Timing) Phase(phase string) (time.Duration, error) {
+	switch phase {
+	case DNS:
+		return t.DNS, nil
+	case Connect:
+		return t.Connect, nil
+	case TLS:
+		return t.TLS, nil
+	case FirstByte:
+		return t.FirstByte, nil
+	case Total, "":
+		return t.Total, nil
+	}
+	return 0, fmt.Errorf("invalid timing phase '%s'", phase)
+}
+
+// Trace captures the timing of a request with httptrace. It is safe for concurrent use.
+type Trace struct {
+	// Start is the time when the request started.
+	Start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	timing       Timing
+	finished     bool
+	mutex        sync.Mutex
+}
+
+// traceKey is the context key of the trace of a request.
+type traceKey struct{}
+
+// NewTrace creates a trace of a request starting now.
+func NewTrace() *Trace {
+	return &Trace{Start: time.Now()}
+}
+
+// WithTrace returns a copy of the context that captures the timing of the requests in the
+// trace. The trace is available for the transports of the requests (e.g. the HAR recorder)
+// with FromContext.
+func WithTrace(ctx context.Context, t *Trace) context.Context {
+	ctx = httptrace.WithClientTrace(ctx, t.ClientTrace())
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+// FromContext returns the trace of the context, or nil if there is no trace.
+func FromContext(ctx context.Context) *Trace {
+	t, _ := ctx.Value(traceKey{}).(*Trace)
+	return t
+}
+
+func (t *Trace) record(f func()) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	f()
+}
+
+// ClientTrace returns the httptrace hooks to capture the timing of the request.
+func (t *Trace) ClientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.record(func() { t.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.record(func() { t.timing.DNS = time.Since(t.dnsStart) })
+		},
+		ConnectStart: func(network, addr string) {
+			t.record(func() { t.connectStart = time.Now() })
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.record(func() { t.timing.Connect = time.Since(t.connectStart) })
+		},
+		TLSHandshakeStart: func() {
+			t.record(func() { t.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.record(func() { t.timing.TLS = time.Since(t.tlsStart) })
+		},
+		GotFirstResponseByte: func() {
+			t.record(func() { t.timing.FirstByte = time.Since(t.Start) })
+		},
+	}
+}
+
+// GotResponse records the time to the first byte when the response headers are received,
+// if the transport does not trigger the httptrace hooks (e.g. HTTP/3).
+func (t *Trace) GotResponse() {
+	t.record(func() {
+		if t.timing.FirstByte == 0 {
+			t.timing.FirstByte = time.Since(t.Start)
+		}
+	})
+}
+
+// Finish returns the timing with the total duration until now. The total duration is fixed
+// by the first call, so that all the users of a shared trace get the same timing.
+func (t *Trace) Finish() Timing {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !t.finished {
+		t.timing.Total = time.Since(t.Start)
+		t.finished = true
+	}
+	return t.timing
+}
+
+// Snapshot returns the timing with the total duration until now, without finishing the trace.
+func (t *Trace) Snapshot() Timing {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	timing := t.timing
+	if !t.finished {
+		timing.Total = time.Since(t.Start)
+	}
+	return timing
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhase(t *testing.T) {
+	timing := Timing{DNS: 1, Connect: 2, TLS: 3, FirstByte: 4, Total: 5}
+	for phase, expected := range map[string]time.Duration{
+		DNS: 1, Connect: 2, TLS: 3, FirstByte: 4, Total: 5, "": 5,
+	} {
+		d, err := timing.Phase(phase)
+		require.NoError(t, err)
+		require.Equal(t, expected, d)
+	}
+	_, err := timing.Phase("send")
+	require.Error(t, err)
+}
+
+func TestTrace(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer ts.Close()
+	trace := NewTrace()
+	req, err := http.NewRequest(http.MethodGet, ts.URL, http.NoBody)
+	require.NoError(t, err)
+	req = req.WithContext(WithTrace(req.Context(), trace))
+	require.Equal(t, trace, FromContext(req.Context()))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	firstByte := trace.Finish().FirstByte
+	trace.GotResponse()
+	timing := trace.Finish()
+	require.Equal(t, firstByte, timing.FirstByte)
+	require.GreaterOrEqual(t, timing.FirstByte, 10*time.Millisecond)
+	require.GreaterOrEqual(t, timing.Total, timing.FirstByte)
+	require.Greater(t, timing.Connect, time.Duration(0))
+	time.Sleep(time.Millisecond)
+	require.Equal(t, timing, trace.Finish())
+	require.Greater(t, trace.Snapshot().Total, time.Duration(0))
+	require.Nil(t, FromContext(context.Background()))
+
+	trace = NewTrace()
+	trace.GotResponse()
+	snapshot := trace.Snapshot()
+	time.Sleep(time.Millisecond)
+	require.Greater(t, trace.Finish().Total, snapshot.Total)
+	require.Greater(t, trace.Finish().FirstByte, time.Duration(0))
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	durations := []time.Duration{5, 1, 4, 2, 3, 10, 9, 8, 7, 6}
+	tests := []struct {
+		percentile float64
+		want       time.Duration
+	}{
+		{percentile: 50, want: 5},
+		{percentile: 90, want: 9},
+		{percentile: 95, want: 10},
+		{percentile: 100, want: 10},
+		{percentile: 1, want: 1},
+	}
+	for _, tt := range tests {
+		if got := Percentile(durations, tt.percentile); got != tt.want {
+			t.Errorf("Percentile(%v) = %v, want %v", tt.percentile, got, tt.want)
+		}
+	}
+	require.Equal(t, time.Duration(0), Percentile(nil, 50))
+}
+
+func TestParsePercentile(t *testing.T) {
+	tests := []struct {
+		percentile string
+		want       float64
+		wantErr    bool
+	}{
+		{percentile: "95", want: 95},
+		{percentile: "p99", want: 99},
+		{percentile: "99.9", want: 99.9},
+		{percentile: "0", wantErr: true},
+		{percentile: "101", wantErr: true},
+		{percentile: "pxx", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePercentile(tt.percentile)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePercentile(%s) error = %v, wantErr %v", tt.percentile, err, tt.wantErr)
+		}
+		require.Equal(t, tt.want, got)
+	}
+}
+
+func TestResponseTiming(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	ctx := golium.InitializeContext(context.Background())
+	s := &Session{}
+	s.ConfigureEndpoint(ctx, ts.URL)
+
+	require.Error(t, s.ValidateResponseTime(ctx, "", time.Second))
+	require.Error(t, s.SendHTTPRequests(ctx, http.MethodGet, 0))
+	require.NoError(t, s.SendHTTPRequests(ctx, http.MethodGet, 5))
+	require.Len(t, s.Timings, 5)
+	require.GreaterOrEqual(t, s.Timing.FirstByte, 20*time.Millisecond)
+	require.GreaterOrEqual(t, s.Timing.Total, s.Timing.FirstByte)
+
+	require.NoError(t, s.ValidateResponseTime(ctx, "", time.Second))
+	require.NoError(t, s.ValidateResponseTime(ctx, TimingFirstByte, time.Second))
+	require.Error(t, s.ValidateResponseTime(ctx, TimingTotal, 20*time.Millisecond))
+	require.Error(t, s.ValidateResponseTime(ctx, "invalid", time.Second))
+	require.NoError(t, s.ValidateResponseTimePercentile(ctx, "", 95, time.Second))
+	require.Error(t, s.ValidateResponseTimePercentile(ctx, "", 50, 10*time.Millisecond))
+
+	s.StoreTimingInContext(ctx)
+	total, ok := golium.GetContext(ctx).Get("http.timing.total").(float64)
+	require.True(t, ok)
+	require.GreaterOrEqual(t, total, 20.0)
+}
+
+func TestResponseTimingTimeouts(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("slow") != "" {
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer ts.Close()
+	ctx := golium.InitializeContext(context.Background())
+	s := &Session{}
+	s.ConfigureEndpoint(ctx, ts.URL)
+	s.SetHTTPResponseTimeout(ctx, 100)
+	require.NoError(t, s.SendHTTPRequests(ctx, http.MethodGet, 3))
+	require.NoError(t, s.ValidateResponseTimePercentile(ctx, "", 95, time.Second))
+
+	s.ConfigureQueryParams(map[string][]string{"slow": {"true"}})
+	require.NoError(t, s.SendHTTPRequest(ctx, http.MethodGet))
+	require.True(t, s.Timedout)
+	require.Equal(t, 1, s.Timeouts)
+	require.Error(t, s.ValidateResponseTime(ctx, "", time.Second))
+	err := s.ValidateResponseTimePercentile(ctx, "", 95, time.Second)
+	require.ErrorContains(t, err, "1 of 4 HTTP requests timed out")
+
+	require.NoError(t, s.SendHTTPLoad(ctx, http.MethodGet, 2, 2))
+	require.Equal(t, 2, s.Timeouts)
+	require.Error(t, s.ValidateResponseTimePercentile(ctx, "", 95, time.Second))
+}
@@ -0,0 +1,316 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TelefonicaTC2Tech/golium"
+)
+
+const confTLSProfile = "[CONF:tls.%s.%s]"
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSOptions contains the TLS settings of the HTTP client.
+// The certificates and keys can be either a path to a PEM file or the PEM content.
+type TLSOptions struct {
+	// Certificate of the client for mutual TLS.
+	Certificate string
+	// Key is the private key of the client certificate.
+	Key string
+	// CA is a bundle of certificates to verify the server certificate.
+	CA string
+	// ServerName overrides the SNI and the name used to verify the server certificate.
+	ServerName string
+	// MinVersion is the minimum TLS version (0 for the default).
+	MinVersion uint16
+	// MaxVersion is the maximum TLS version (0 for the default).
+	MaxVersion uint16
+	// CipherSuites pins the cipher suites for TLS 1.0-1.2.
+	CipherSuites []uint16
+}
+
+// IsEmpty returns true if there is no TLS setting.
+func (o *TLSOptions) IsEmpty() bool {
+	return o.Certificate == "" && o.Key == "" && o.CA == "" && o.ServerName == "" &&
+		o.MinVersion == 0 && o.MaxVersion == 0 && len(o.CipherSuites) == 0
+}
+
+// Config builds a tls.Config with the TLS options.
+func (o *TLSOptions) Config(insecureSkipVerify bool) (*tls.Config, error) {
+	// #nosec G402
+	config := &tls.Config{
+		InsecureSkipVerify: insecureSkipVerify,
+		ServerName:         o.ServerName,
+		MinVersion:         o.MinVersion,
+		MaxVersion:         o.MaxVersion,
+		CipherSuites:       o.CipherSuites,
+	}
+	if o.Certificate != "" || o.Key != "" {
+		certPEM, err := golium.LoadPEM(o.Certificate)
+		if err != nil {
+			return nil, fmt.Errorf("failed loading client certificate: %w", err)
+		}
+		keyPEM, err := golium.LoadPEM(o.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed loading client key: %w", err)
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate and key pair: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if o.CA != "" {
+		caPEM, err := golium.LoadPEM(o.CA)
+		if err != nil {
+			return nil, fmt.Errorf("failed loading CA certificates: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("no valid CA certificate found")
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// ParseTLSVersion converts a TLS version (e.g. "1.2" or "TLS 1.2") to its tls constant.
+func ParseTLSVersion(version string) (uint16, error) {
+	v := strings.TrimSpace(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(version)), "TLS"))
+	v = strings.TrimPrefix(v, "V")
+	if tlsVersion, ok := tlsVersions[v]; ok {
+		return tlsVersion, nil
+	}
+	return 0, fmt.Errorf("invalid TLS version '%s'", version)
+}
+
+// ParseCipherSuites converts a list of cipher suite names
+// (e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256) to their identifiers.
+// The TLS 1.3 cipher suites (e.g. TLS_AES_128_GCM_SHA256) are rejected because they are
+// not configurable.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	suites := make(map[string]*tls.CipherSuite)
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		suites[suite.Name] = suite
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		suite, ok := suites[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("invalid cipher suite '%s'", name)
+		}
+		if isTLS13CipherSuite(suite) {
+			return nil, fmt.Errorf("cipher suite '%s' is only for TLS 1.3 and it is not "+
+				"configurable", name)
+		}
+		ids = append(ids, suite.ID)
+	}
+	return ids, nil
+}
+
+// isTLS13CipherSuite returns true if the cipher suite is only supported by TLS 1.3.
+func isTLS13CipherSuite(suite *tls.CipherSuite) bool {
+	for _, version := range suite.SupportedVersions {
+		if version != tls.VersionTLS13 {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadTLSOptions loads the TLS options from the environment configuration under the key
+// "tls.{name}" with the properties: certificate, key, ca, server-name, min-version,
+// max-version and cipher-suites (comma-separated list).
+func LoadTLSOptions(ctx context.Context, name string) (*TLSOptions, error) {
+	options := &TLSOptions{
+		Certificate: golium.ValueAsStringOrEmpty(ctx, fmt.Sprintf(confTLSProfile, name, "certificate")),
+		Key:         golium.ValueAsStringOrEmpty(ctx, fmt.Sprintf(confTLSProfile, name, "key")),
+		CA:          golium.ValueAsStringOrEmpty(ctx, fmt.Sprintf(confTLSProfile, name, "ca")),
+		ServerName: golium.ValueAsStringOrEmpty(ctx,
+			fmt.Sprintf(confTLSProfile, name, "server-name")),
+	}
+	var err error
+	minVersion := golium.ValueAsStringOrEmpty(ctx, fmt.Sprintf(confTLSProfile, name, "min-version"))
+	if minVersion != "" {
+		if options.MinVersion, err = ParseTLSVersion(minVersion); err != nil {
+			return nil, err
+		}
+	}
+	maxVersion := golium.ValueAsStringOrEmpty(ctx, fmt.Sprintf(confTLSProfile, name, "max-version"))
+	if maxVersion != "" {
+		if options.MaxVersion, err = ParseTLSVersion(maxVersion); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateTLSVersions(options.MinVersion, options.MaxVersion); err != nil {
+		return nil, err
+	}
+	cipherSuites := golium.ValueAsStringOrEmpty(ctx,
+		fmt.Sprintf(confTLSProfile, name, "cipher-suites"))
+	if cipherSuites != "" {
+		if options.CipherSuites, err = ParseCipherSuites(strings.Split(cipherSuites, ",")); err != nil {
+			return nil, err
+		}
+	}
+	if options.IsEmpty() {
+		return nil, fmt.Errorf("no TLS configuration found for '%s'", name)
+	}
+	return options, nil
+}
+
+// ConfigureTLS configures the TLS options of the HTTP client.
+func (s *Session) ConfigureTLS(ctx context.Context, options *TLSOptions) error {
+	if _, err := options.Config(s.InsecureSkipVerify); err != nil {
+		return err
+	}
+	s.TLS = *options
+	return nil
+}
+
+// ConfigureClientCertificate configures the client certificate and key for mutual TLS.
+func (s *Session) ConfigureClientCertificate(ctx context.Context, cert, key string) error {
+	options := s.TLS
+	options.Certificate = cert
+	options.Key = key
+	return s.ConfigureTLS(ctx, &options)
+}
+
+// ConfigureCA configures the CA certificates to verify the server certificate.
+func (s *Session) ConfigureCA(ctx context.Context, ca string) error {
+	options := s.TLS
+	options.CA = ca
+	return s.ConfigureTLS(ctx, &options)
+}
+
+// ConfigureServerName configures the server name for SNI and certificate verification.
+func (s *Session) ConfigureServerName(ctx context.Context, serverName string) {
+	s.TLS.ServerName = serverName
+}
+
+// ConfigureTLSVersions configures the minimum and/or maximum TLS versions.
+// An empty version keeps the current value.
+func (s *Session) ConfigureTLSVersions(ctx context.Context, minVersion, maxVersion string) error {
+	minTLS, maxTLS := s.TLS.MinVersion, s.TLS.MaxVersion
+	var err error
+	if minVersion != "" {
+		if minTLS, err = ParseTLSVersion(minVersion); err != nil {
+			return err
+		}
+	}
+	if maxVersion != "" {
+		if maxTLS, err = ParseTLSVersion(maxVersion); err != nil {
+			return err
+		}
+	}
+	if err := validateTLSVersions(minTLS, maxTLS); err != nil {
+		return err
+	}
+	s.TLS.MinVersion, s.TLS.MaxVersion = minTLS, maxTLS
+	return nil
+}
+
+// validateTLSVersions checks that the minimum TLS version is not higher than the maximum one.
+// A version 0 is the default one.
+func validateTLSVersions(minVersion, maxVersion uint16) error {
+	if minVersion != 0 && maxVersion != 0 && minVersion > maxVersion {
+		return fmt.Errorf("minimum TLS version '%s' is higher than maximum TLS version '%s'",
+			tls.VersionName(minVersion), tls.VersionName(maxVersion))
+	}
+	return nil
+}
+
+// ConfigureCipherSuites pins the cipher suites of the HTTP client.
+func (s *Session) ConfigureCipherSuites(ctx context.Context, names []string) error {
+	suites, err := ParseCipherSuites(names)
+	if err != nil {
+		return err
+	}
+	s.TLS.CipherSuites = suites
+	return nil
+}
+
+func (s *Session) responseTLS() (*tls.ConnectionState, error) {
+	if s.Response.HTTPResponse == nil || s.Response.HTTPResponse.TLS == nil {
+		return nil, errors.New("no TLS connection in the HTTP response")
+	}
+	return s.Response.HTTPResponse.TLS, nil
+}
+
+// ValidateTLSVersion validates the TLS version negotiated with the server.
+func (s *Session) ValidateTLSVersion(ctx context.Context, expectedVersion string) error {
+	state, err := s.responseTLS()
+	if err != nil {
+		return err
+	}
+	expected, err := ParseTLSVersion(expectedVersion)
+	if err != nil {
+		return err
+	}
+	if state.Version != expected {
+		return fmt.Errorf("TLS version mismatch: expected '%s', actual '%s'",
+			tls.VersionName(expected), tls.VersionName(state.Version))
+	}
+	return nil
+}
+
+// ValidateServerCertificateSubject validates the subject of the server certificate
+// (e.g. "CN=example.com,O=Example").
+func (s *Session) ValidateServerCertificateSubject(ctx context.Context, expected string) error {
+	state, err := s.responseTLS()
+	if err != nil {
+		return err
+	}
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("no server certificate in the TLS connection")
+	}
+	subject := state.PeerCertificates[0].Subject.String()
+	if subject != expected {
+		return fmt.Errorf("server certificate subject mismatch: expected '%s', actual '%s'",
+			expected, subject)
+	}
+	return nil
+}
+
+// ValidateServerCertificateExpiry validates that the server certificate is still valid
+// for, at least, the number of days.
+func (s *Session) ValidateServerCertificateExpiry(ctx context.Context, days int) error {
+	state, err := s.responseTLS()
+	if err != nil {
+		return err
+	}
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("no server certificate in the TLS connection")
+	}
+	notAfter := state.PeerCertificates[0].NotAfter
+	limit := time.Now().Add(time.Duration(days) * 24 * time.Hour)
+	if notAfter.Before(limit) {
+		return fmt.Errorf("server certificate expires at '%s', before '%d' days",
+			notAfter.Format(time.RFC3339), days)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM string
+	keyPEM  string
+}
+
+// newTestCertificate creates a certificate signed by parent (self-signed if parent is nil).
+func newTestCertificate(t *testing.T, subject pkix.Name, parent *testCertificate,
+) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	parentCert, parentKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		keyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+	}
+}
+
+func newMutualTLSServer(t *testing.T, ca, server *testCertificate) *httptest.Server {
+	serverCert, err := tls.X509KeyPair([]byte(server.certPEM), []byte(server.keyPEM))
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	ts.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestSendHTTPRequestWithMutualTLS(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ca := newTestCertificate(t, pkix.Name{CommonName: "Golium CA"}, nil)
+	server := newTestCertificate(t,
+		pkix.Name{CommonName: "localhost", Organization: []string{"Golium"}}, ca)
+	client := newTestCertificate(t, pkix.Name{CommonName: "client"}, ca)
+	ts := newMutualTLSServer(t, ca, server)
+
+	caFile := "./ca.pem"
+	os.WriteFile(caFile, []byte(ca.certPEM), os.ModePerm)
+	defer os.Remove(caFile)
+
+	tests := []struct {
+		name       string
+		cert       string
+		key        string
+		ca         string
+		maxVersion string
+		wantErr    bool
+	}{
+		{
+			name:    "without client certificate",
+			ca:      caFile,
+			wantErr: true,
+		},
+		{
+			name:    "without CA",
+			cert:    client.certPEM,
+			key:     client.keyPEM,
+			wantErr: true,
+		},
+		{
+			name:    "with client certificate and CA file",
+			cert:    client.certPEM,
+			key:     client.keyPEM,
+			ca:      caFile,
+			wantErr: false,
+		},
+		{
+			name:       "with maximum TLS version",
+			cert:       client.certPEM,
+			key:        client.keyPEM,
+			ca:         ca.certPEM,
+			maxVersion: "1.2",
+			wantErr:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := &Session{}
+			s.Request.Endpoint = ts.URL
+			if tt.cert != "" {
+				require.NoError(t, s.ConfigureClientCertificate(ctx, tt.cert, tt.key))
+			}
+			if tt.ca != "" {
+				require.NoError(t, s.ConfigureCA(ctx, tt.ca))
+			}
+			require.NoError(t, s.ConfigureTLSVersions(ctx, "", tt.maxVersion))
+			err := s.SendHTTPRequest(ctx, http.MethodGet)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Session.SendHTTPRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			require.NoError(t, s.ValidateStatusCode(ctx, http.StatusNoContent))
+			require.NoError(t, s.ValidateServerCertificateSubject(ctx, "CN=localhost,O=Golium"))
+			require.Error(t, s.ValidateServerCertificateSubject(ctx, "CN=other"))
+			require.NoError(t, s.ValidateServerCertificateExpiry(ctx, 30))
+			require.Error(t, s.ValidateServerCertificateExpiry(ctx, 365))
+			if tt.maxVersion != "" {
+				require.NoError(t, s.ValidateTLSVersion(ctx, "TLS 1.2"))
+			} else {
+				require.NoError(t, s.ValidateTLSVersion(ctx, "1.3"))
+			}
+		})
+	}
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    uint16
+		wantErr bool
+	}{
+		{version: "1.2", want: tls.VersionTLS12},
+		{version: "TLS 1.3", want: tls.VersionTLS13},
+		{version: "TLSv1.1", want: tls.VersionTLS11},
+		{version: "2.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := ParseTLSVersion(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTLSVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfigureTLSVersions(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		minVersion string
+		maxVersion string
+		wantMin    uint16
+		wantMax    uint16
+		wantErr    bool
+	}{
+		{name: "min and max", minVersion: "1.2", maxVersion: "1.3",
+			wantMin: tls.VersionTLS12, wantMax: tls.VersionTLS13},
+		{name: "same version", minVersion: "1.2", maxVersion: "1.2",
+			wantMin: tls.VersionTLS12, wantMax: tls.VersionTLS12},
+		{name: "min higher than max", minVersion: "1.3", maxVersion: "1.2", wantErr: true},
+		{name: "invalid version", minVersion: "2.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{}
+			err := s.ConfigureTLSVersions(ctx, tt.minVersion, tt.maxVersion)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Session.ConfigureTLSVersions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			require.Equal(t, tt.wantMin, s.TLS.MinVersion)
+			require.Equal(t, tt.wantMax, s.TLS.MaxVersion)
+		})
+	}
+	s := &Session{}
+	require.NoError(t, s.ConfigureTLSVersions(ctx, "", "1.2"))
+	require.Error(t, s.ConfigureTLSVersions(ctx, "1.3", ""))
+	require.Equal(t, uint16(0), s.TLS.MinVersion)
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	suites, err := ParseCipherSuites([]string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"})
+	require.NoError(t, err)
+	require.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, suites)
+	_, err = ParseCipherSuites([]string{"TLS_INVALID"})
+	require.Error(t, err)
+	_, err = ParseCipherSuites([]string{"TLS_AES_128_GCM_SHA256"})
+	require.ErrorContains(t, err, "only for TLS 1.3")
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/TelefonicaTC2Tech/golium/steps/http/har"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// newHTTPClient creates an HTTP client with the configuration of the session
+// (timeout, redirection policy, cookie jar, protocol, TLS and proxy settings).
+// The client must be released with closeHTTPClient.
+func (s *Session) newHTTPClient(ctx context.Context) (*http.Client, error) {
+	client := &http.Client{Timeout: s.Timeout, Jar: s.CookieJar}
+	if s.NoRedirect {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	if s.Protocol == ProtocolHTTP3 {
+		if s.Proxy.IsEnabled() {
+			return nil, errors.New("proxy is not supported with HTTP/3")
+		}
+		tlsConfig, err := s.TLS.Config(s.InsecureSkipVerify)
+		if err != nil {
+			return nil, fmt.Errorf("failed configuring TLS: %w", err)
+		}
+		client.Transport = har.Wrap(ctx, &http3.Transport{TLSClientConfig: tlsConfig})
+		return client, nil
+	}
+	if s.InsecureSkipVerify || !s.TLS.IsEmpty() || s.Protocol != "" || s.Proxy != nil {
+		tlsConfig, err := s.TLS.Config(s.InsecureSkipVerify)
+		if err != nil {
+			return nil, fmt.Errorf("failed configuring TLS: %w", err)
+		}
+		tr, err := s.Proxy.Transport()
+		if err != nil {
+			return nil, fmt.Errorf("failed configuring proxy: %w", err)
+		}
+		tr.TLSClientConfig = tlsConfig
+		tr.Protocols = s.protocols()
+		client.Transport = tr
+	}
+	client.Transport = har.Wrap(ctx, client.Transport)
+	return client, nil
+}
+
+// closeHTTPClient releases the resources of the transport when it is not reusable
+// between clients (e.g. the UDP socket of HTTP/3 or the idle connections).
+func closeHTTPClient(client *http.Client) {
+	transport := har.Unwrap(client.Transport)
+	if closer, ok := transport.(io.Closer); ok {
+		closer.Close()
+		return
+	}
+	if transport != nil && transport != http.DefaultTransport {
+		client.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+)
+
+// Content types of the request bodies.
+const (
+	XMLContentType  = "application/xml"
+	TextContentType = "text/plain"
+)
+
+// ConfigureRequestBodyWithContentType writes the body in the HTTP request with the content type.
+func (s *Session) ConfigureRequestBodyWithContentType(ctx context.Context, contentType,
+	body string,
+) {
+	s.Request.RequestBody = []byte(body)
+	if s.Request.Headers == nil {
+		s.Request.Headers = make(map[string][]string)
+	}
+	s.Request.Headers["Content-Type"] = []string{contentType}
+}
+
+// ConfigureRequestBodyXML writes the body in the HTTP request as XML.
+func (s *Session) ConfigureRequestBodyXML(ctx context.Context, body string) {
+	s.ConfigureRequestBodyWithContentType(ctx, XMLContentType, body)
+}
+
+// ConfigureRequestBodyText writes the body in the HTTP request as plain text.
+func (s *Session) ConfigureRequestBodyText(ctx context.Context, body string) {
+	s.ConfigureRequestBodyWithContentType(ctx, TextContentType, body)
+}
+
+// ConfigureXMLNamespaces configures the prefixes of the namespaces used in the XPath
+// expressions. Without namespaces, the prefixes of the XML document are matched literally.
+func (s *Session) ConfigureXMLNamespaces(ctx context.Context, namespaces map[string]string) {
+	s.XMLNamespaces = namespaces
+}
+
+// xmlValue evaluates the XPath expression in the XML body of the HTTP response.
+// It returns the text of the first node (nil if there is no node), or the result of
+// the XPath function (e.g. count or boolean expressions).
+func (s *Session) xmlValue(expr string) (interface{}, error) {
+	doc, err := xmlquery.Parse(bytes.NewReader(s.Response.ResponseBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing the XML response body: %w", err)
+	}
+	var compiled *xpath.Expr
+	if len(s.XMLNamespaces) > 0 {
+		compiled, err = xpath.CompileWithNS(expr, s.XMLNamespaces)
+	} else {
+		compiled, err = xpath.Compile(expr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath expression '%s': %w", expr, err)
+	}
+	switch result := compiled.Evaluate(xmlquery.CreateXPathNavigator(doc)).(type) {
+	case *xpath.NodeIterator:
+		if !result.MoveNext() {
+			return nil, nil
+		}
+		return result.Current().Value(), nil
+	case float64:
+		return fmt.Sprint(result), nil
+	default:
+		return result, nil
+	}
+}
+
+// ValidateResponseBodyXMLProperties validates a list of XPath expressions in the XML body of
+// the HTTP response. The values are compared as text, and a nil value expects no node.
+func (s *Session) ValidateResponseBodyXMLProperties(ctx context.Context,
+	props map[string]interface{},
+) error {
+	for expr, expectedValue := range props {
+		value, err := s.xmlValue(expr)
+		if err != nil {
+			return err
+		}
+		if value == nil || expectedValue == nil {
+			if value != expectedValue {
+				return fmt.Errorf("mismatch of XML property '%s': expected '%v', actual '%v'",
+					expr, expectedValue, value)
+			}
+			continue
+		}
+		if fmt.Sprint(value) != fmt.Sprint(expectedValue) {
+			return fmt.Errorf("mismatch of XML property '%s': expected '%v', actual '%v'",
+				expr, expectedValue, value)
+		}
+	}
+	return nil
+}
+
+// StoreResponseBodyXMLPropertyInContext extracts the value of an XPath expression from
+// the XML body of the HTTP response and stores it in the context.
+func (s *Session) StoreResponseBodyXMLPropertyInContext(ctx context.Context, expr,
+	ctxtKey string,
+) error {
+	value, err := s.xmlValue(expr)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return fmt.Errorf("XML property '%s' not found in the HTTP response body", expr)
+	}
+	golium.GetContext(ctx).Put(ctxtKey, value)
+	return nil
+}
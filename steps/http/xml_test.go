@@ -0,0 +1,157 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const soapResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"
+    xmlns:u="http://golium.test/users">
+  <soap:Body>
+    <u:GetUserResponse>
+      <u:user id="1">
+        <u:name>alice</u:name>
+        <u:roles><u:role>admin</u:role><u:role>staff</u:role></u:roles>
+      </u:user>
+    </u:GetUserResponse>
+  </soap:Body>
+</soap:Envelope>`
+
+// newSOAPServer answers with the SOAP response and the content type of the request.
+func newSOAPServer(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+		w.Header().Set("X-Body", string(body))
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(soapResponse))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestSendRequestWithXMLBody(t *testing.T) {
+	os.MkdirAll(logsPath, os.ModePerm)
+	defer os.RemoveAll(logsPath)
+	ts := newSOAPServer(t)
+	tests := []struct {
+		name        string
+		configure   func(ctx context.Context, s *Session)
+		contentType string
+	}{
+		{
+			name: "XML body",
+			configure: func(ctx context.Context, s *Session) {
+				s.ConfigureRequestBodyXML(ctx, "<GetUser/>")
+			},
+			contentType: XMLContentType,
+		},
+		{
+			name: "text body",
+			configure: func(ctx context.Context, s *Session) {
+				s.ConfigureRequestBodyText(ctx, "<GetUser/>")
+			},
+			contentType: TextContentType,
+		},
+		{
+			name: "SOAP body",
+			configure: func(ctx context.Context, s *Session) {
+				s.ConfigureRequestBodyWithContentType(ctx, "text/xml; charset=utf-8", "<GetUser/>")
+			},
+			contentType: "text/xml; charset=utf-8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := &Session{}
+			s.ConfigureEndpoint(ctx, ts.URL)
+			tt.configure(ctx, s)
+			require.NoError(t, s.SendHTTPRequest(ctx, http.MethodPost))
+			require.NoError(t, s.ValidateResponseHeaders(ctx, map[string][]string{
+				"X-Content-Type": {tt.contentType},
+				"X-Body":         {"<GetUser/>"},
+			}))
+		})
+	}
+}
+
+func TestValidateResponseBodyXMLProperties(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		namespaces map[string]string
+		props      map[string]interface{}
+		wantErr    bool
+	}{
+		{
+			name: "document prefixes",
+			props: map[string]interface{}{
+				"//u:user/u:name":          "alice",
+				"//u:user/@id":             float64(1),
+				"count(//u:role)":          "2",
+				"//u:role[2]":              "staff",
+				"//u:user/u:email":         nil,
+				"boolean(//soap:Body)":     true,
+				"/soap:Envelope//u:name":   "alice",
+				"string(//u:roles/u:role)": "admin",
+			},
+		},
+		{
+			name:       "configured namespaces",
+			namespaces: map[string]string{"users": "http://golium.test/users"},
+			props:      map[string]interface{}{"//users:name": "alice"},
+		},
+		{
+			name:    "mismatch",
+			props:   map[string]interface{}{"//u:name": "bob"},
+			wantErr: true,
+		},
+		{
+			name:    "missing node",
+			props:   map[string]interface{}{"//u:email": "alice@golium.test"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid XPath",
+			props:   map[string]interface{}{"//u:name[": "alice"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{}
+			s.Response.ResponseBody = []byte(soapResponse)
+			s.ConfigureXMLNamespaces(ctx, tt.namespaces)
+			err := s.ValidateResponseBodyXMLProperties(ctx, tt.props)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Session.ValidateResponseBodyXMLProperties() error = %v, wantErr %v",
+					err, tt.wantErr)
+			}
+		})
+	}
+	s := &Session{}
+	s.Response.ResponseBody = []byte("{}")
+	require.Error(t, s.ValidateResponseBodyXMLProperties(ctx, map[string]interface{}{"//a": "b"}))
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build xsd
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/TelefonicaTC2Tech/golium"
+	"github.com/cucumber/godog"
+	xsdvalidate "github.com/terminalstatic/go-xsd-validate"
+)
+
+var (
+	xsdInit    sync.Once
+	xsdInitErr error
+)
+
+// initializeXMLSchemaSteps adds the step to validate XML bodies against XSD schemas.
+// It is only available when building with the tag "xsd" because it requires libxml2.
+func initializeXMLSchemaSteps(ctx context.Context, scenCtx *godog.ScenarioContext,
+	session *Session,
+) {
+	scenCtx.Step(`^the HTTP response body must comply with the XML schema "([^"]*)"$`,
+		func(schema string) error {
+			return session.ValidateResponseBodyXMLSchema(ctx, golium.ValueAsString(ctx, schema))
+		})
+}
+
+// ValidateResponseBodyXMLSchema validates the response body against the XML schema
+// located at {schemas directory}/{schemaName}.xsd.
+func (s *Session) ValidateResponseBodyXMLSchema(ctx context.Context, schemaName string) error {
+	schemaPath := fmt.Sprintf("%s/%s.xsd", golium.GetConfig().Dir.Schemas, schemaName)
+	if err := validateXMLSchema(schemaPath, s.Response.ResponseBody); err != nil {
+		return fmt.Errorf("invalid response body according to schema '%s': %w", schemaName, err)
+	}
+	return nil
+}
+
+// validateXMLSchema validates the XML document against the XSD file with libxml2.
+func validateXMLSchema(schemaPath string, document []byte) error {
+	xsdInit.Do(func() { xsdInitErr = xsdvalidate.Init() })
+	if xsdInitErr != nil {
+		return xsdInitErr
+	}
+	handler, err := xsdvalidate.NewXsdHandlerUrl(schemaPath, xsdvalidate.ParsErrDefault)
+	if err != nil {
+		return err
+	}
+	defer handler.Free()
+	return handler.ValidateMem(document, xsdvalidate.ValidErrDefault)
+}
@@ -0,0 +1,30 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !xsd
+
+package http
+
+import (
+	"context"
+
+	"github.com/cucumber/godog"
+)
+
+// initializeXMLSchemaSteps does not add the XML schema step without libxml2.
+// Build with the tag "xsd" to enable it.
+func initializeXMLSchemaSteps(ctx context.Context, scenCtx *godog.ScenarioContext,
+	session *Session,
+) {
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 Telefonica Cybersecurity & Cloud Tech SL
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build xsd
+
+package http
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TelefonicaTC2Tech/golium"
+)
+
+const userSchema = `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="user">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="name" type="xs:string"/>
+        <xs:element name="age" type="xs:positiveInteger"/>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+
+func TestValidateResponseBodyXMLSchema(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "user.xsd"), []byte(userSchema),
+		os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	config := golium.GetConfig()
+	schemasDir := config.Dir.Schemas
+	config.Dir.Schemas = dir
+	defer func() { config.Dir.Schemas = schemasDir }()
+
+	tests := []struct {
+		name    string
+		schema  string
+		body    string
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			schema: "user",
+			body:   "<user><name>alice</name><age>30</age></user>",
+		},
+		{
+			name:    "invalid",
+			schema:  "user",
+			body:    "<user><name>alice</name><age>-1</age></user>",
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			schema:  "user",
+			body:    "<user>",
+			wantErr: true,
+		},
+		{
+			name:    "schema not found",
+			schema:  "users",
+			body:    "<user><name>alice</name><age>30</age></user>",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{}
+			s.Response.ResponseBody = []byte(tt.body)
+			err := s.ValidateResponseBodyXMLSchema(context.Background(), tt.schema)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Session.ValidateResponseBodyXMLSchema() error = %v, wantErr %v",
+					err, tt.wantErr)
+			}
+		})
+	}
+}
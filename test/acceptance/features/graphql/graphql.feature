@@ -0,0 +1,86 @@
+Feature: GraphQL client
+
+  @graphql
+  Scenario: Send a GraphQL query
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "POST",
+          "path": "/graphql/users"
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["application/json"]
+          },
+          "body": "{\"data\": {\"user\": {\"id\": \"1\", \"name\": \"alice\", \"roles\": [\"admin\"]}}}"
+        }
+      }
+      """
+      And the GraphQL endpoint "[CONF:httpMockUrl]/graphql/users"
+      And the GraphQL query
+      """
+      query GetUser($id: ID!) {
+        user(id: $id) {
+          id
+          name
+          roles
+        }
+      }
+      """
+      And the GraphQL variables
+        | variable | value |
+        | id       | 1     |
+      And the GraphQL operation name "GetUser"
+     When I send the GraphQL request
+     Then the HTTP status code must be "200"
+      And the GraphQL response must not have errors
+      And the GraphQL response data must have the properties
+        | property     | value |
+        | user.id      | 1     |
+        | user.name    | alice |
+        | user.roles.0 | admin |
+      And I store the GraphQL data "user.name" in context "name"
+      And the GraphQL response data must have the properties
+        | property  | value       |
+        | user.name | [CTXT:name] |
+
+  @graphql
+  Scenario: Send a GraphQL mutation with errors
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "POST",
+          "path": "/graphql/users/errors"
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["application/json"]
+          },
+          "body": "{\"data\": {\"deleteUser\": null}, \"errors\": [{\"message\": \"forbidden\", \"path\": [\"deleteUser\"], \"extensions\": {\"code\": \"FORBIDDEN\"}}]}"
+        }
+      }
+      """
+      And the GraphQL endpoint "[CONF:httpMockUrl]/graphql/users/errors"
+      And the GraphQL mutation
+      """
+      mutation DeleteUser($id: ID!) {
+        deleteUser(id: $id)
+      }
+      """
+      And the GraphQL variables
+        | variable | value      |
+        | id       | [NUMBER:1] |
+     When I send the GraphQL request
+     Then the HTTP status code must be "200"
+      And the GraphQL response data must have the properties
+        | property   | value  |
+        | deleteUser | [NULL] |
+      And the GraphQL response must have an error with the properties
+        | property        | value      |
+        | message         | forbidden  |
+        | path.0          | deleteUser |
+        | extensions.code | FORBIDDEN  |
@@ -0,0 +1,55 @@
+Feature: HTTP response body
+
+  @http @body
+  Scenario: Stream the response body to a file
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "permanent": true,
+        "request": {
+          "method": "GET",
+          "path": "/body/export"
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["text/plain"]
+          },
+          "body": "golium"
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/body/export"
+      And the HTTP response body is stored in a file
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the HTTP response content encoding must be "identity"
+      And the HTTP response body size must be "6" bytes
+      And the HTTP response body size must be less than "10" bytes
+      And the HTTP response compressed body size must be "6" bytes
+      And the HTTP response body must have the "sha256" checksum "4bd7e38f7c061682d5b2fcccdb166af861b38e56c0367f3aff906202cf9868e4"
+      And I store the HTTP response body file path in context "export.file"
+
+  @http @body
+  Scenario: Limit the size of the response body
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/body/small"
+        },
+        "response": {
+          "status": 200,
+          "body": "golium"
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/body/small"
+      And the HTTP response body max size of "6" bytes
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the HTTP response body must be the text
+      """
+      golium
+      """
@@ -0,0 +1,52 @@
+Feature: HTTP client with cookies
+
+  @http @cookies
+  Scenario: Keep the session cookie and the CSRF token between requests
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "POST",
+          "path": "/cookies/login"
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["application/json"],
+            "Set-Cookie": ["session=abc; Path=/; HttpOnly; SameSite=Strict"]
+          },
+          "body": "{\"csrf\": \"csrf-token\"}"
+        }
+      }
+      """
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "POST",
+          "path": "/cookies/account",
+          "headers": {
+            "Cookie": ["session=abc"],
+            "X-Csrf-Token": ["csrf-token"]
+          }
+        },
+        "response": {
+          "status": 204
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]"
+      And the HTTP client uses a cookie jar
+      And the HTTP client sends the CSRF token from the response body property "csrf" in the request header "X-CSRF-Token"
+      And the HTTP path "/cookies/login"
+     When I send a HTTP "POST" request
+     Then the HTTP status code must be "200"
+      And the HTTP response must set the cookies
+          | name    | value | path | httponly | secure | samesite |
+          | session | abc   | /    | true     | false  | Strict   |
+      And the HTTP client must have the cookie "session" with value "abc"
+     When the HTTP path "/cookies/account"
+      And I send a HTTP "POST" request
+     Then the HTTP status code must be "204"
+     When the HTTP client clears the cookies
+     Then the HTTP client must not have the cookie "session"
@@ -0,0 +1,25 @@
+Feature: HAR recording
+
+  @http @har
+  Scenario: Record the HTTP traffic in a HAR file
+    Given the HTTP traffic is recorded in the HAR file "./logs/har/recording.har"
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/har/users"
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["application/json"]
+          },
+          "body": "{\"name\": \"alice\"}"
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/har/users"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the HAR recording must have "2" entries
@@ -0,0 +1,26 @@
+Feature: HTTP load
+
+  @http @load
+  Scenario: Send HTTP requests with concurrency
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "permanent": true,
+        "request": {
+          "method": "GET",
+          "path": "/load/health"
+        },
+        "response": {
+          "status": 200
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/load/health"
+     When I send "100" HTTP "GET" requests with concurrency "10"
+     Then the HTTP load error rate must be less than "1" percent
+      And the HTTP load status code "200" rate must be at least "100" percent
+      And the HTTP load status codes must be
+          | code | count |
+          | 200  | 100   |
+      And the HTTP load throughput must be at least "10" requests per second
+      And the "p95" percentile of the HTTP response time must be less than "1000" millis
@@ -0,0 +1,47 @@
+Feature: HTTP client with OAuth2
+
+  @http @oauth2
+  Scenario: Send a request authorized with an OAuth2 profile
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "permanent": true,
+        "request": {
+          "method": "POST",
+          "path": "/oauth2/token"
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["application/json"]
+          },
+          "body": "{\"access_token\": \"admin-token\", \"token_type\": \"Bearer\", \"expires_in\": 3600}"
+        }
+      }
+      """
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/oauth2/protected",
+          "headers": {
+            "Authorization": ["Bearer admin-token"]
+          }
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["application/json"]
+          },
+          "body": "{\"value\": \"protected resource\"}"
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/oauth2/protected"
+      And the HTTP client authenticates with OAuth2 profile "admin"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the HTTP response body must have the JSON properties
+          | param | value              |
+          | value | protected resource |
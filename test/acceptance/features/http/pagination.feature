@@ -0,0 +1,47 @@
+Feature: HTTP pagination
+
+  @http @pagination
+  Scenario: Follow the next links of an HTTP collection
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/pagination/users"
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["application/json"]
+          },
+          "body": "{\"data\": [{\"id\": 1, \"name\": \"alice\"}, {\"id\": 2, \"name\": \"bob\"}], \"links\": {\"next\": \"/pagination/users/2\"}}"
+        }
+      }
+      """
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/pagination/users/2"
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["application/json"]
+          },
+          "body": "{\"data\": [{\"id\": 3, \"name\": \"carol\"}], \"links\": {\"next\": null}}"
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/pagination/users"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+     When I follow the HTTP pagination collecting "data" in context "users" with the options
+        | param    | value      |
+        | next     | links.next |
+        | maxPages | 5          |
+     Then the HTTP collection must have "3" items
+      And the HTTP collection must have unique values of "id"
+      And the HTTP collection must be sorted by "id"
+      And the HTTP collection must be sorted by "name"
@@ -0,0 +1,21 @@
+Feature: HTTP protocol
+
+  @http @protocol
+  Scenario: Require HTTP/1.1 in the HTTP client
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/protocol/http1"
+        },
+        "response": {
+          "status": 200
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/protocol/http1"
+      And the HTTP client uses the protocol "HTTP/1.1"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the HTTP response protocol must be "HTTP/1.1"
@@ -0,0 +1,43 @@
+Feature: HTTP proxy
+
+  @http @proxy
+  Scenario: Send a HTTP request through a proxy
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/proxy/staging"
+        },
+        "response": {
+          "status": 200
+        }
+      }
+      """
+      And the HTTP endpoint "http://staging.golium.test/proxy/staging"
+      And the HTTP client uses the proxy "[CONF:httpMockUrl]" with the credentials "user" and "pass"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+
+  @http @proxy
+  Scenario: Send a HTTP request without proxy for the host
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/proxy/direct"
+        },
+        "response": {
+          "status": 204
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/proxy/direct"
+      And the HTTP client uses the proxy "socks5://proxy.golium.test:1080"
+      And the HTTP client does not use the proxy for the hosts
+        | host      |
+        | localhost |
+        | 127.0.0.1 |
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "204"
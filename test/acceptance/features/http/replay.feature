@@ -0,0 +1,35 @@
+Feature: HTTP request replay
+
+  @http @replay
+  Scenario: Resend the last HTTP request with headers
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "POST",
+          "path": "/replay/payments"
+        },
+        "response": {
+          "status": 201
+        },
+        "permanent": true
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/replay/payments"
+      And the HTTP request headers
+        | header          | value |
+        | Idempotency-Key | 1     |
+      And the HTTP request body with the JSON
+      """
+      {
+        "amount": 10
+      }
+      """
+     When I send a HTTP "POST" request
+     Then the HTTP status code must be "201"
+     When I resend the last HTTP request
+     Then the HTTP status code must be "201"
+     When I resend the last HTTP request with headers
+        | header          | value |
+        | Idempotency-Key | 2     |
+     Then the HTTP status code must be "201"
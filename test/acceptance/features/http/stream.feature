@@ -0,0 +1,62 @@
+Feature: HTTP streams
+
+  @http @stream
+  Scenario: Wait for Server-Sent Events
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/stream/notifications"
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["text/event-stream"]
+          },
+          "body": "id: 1\nevent: created\ndata: {\"user\": \"alice\"}\n\nid: 2\nevent: deleted\ndata: {\"user\": \"alice\"}\n\n"
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/stream/notifications"
+     When I open a HTTP "GET" SSE stream
+     Then the HTTP status code must be "200"
+      And I wait up to "3" seconds for a HTTP stream event with the type "created"
+      And I wait up to "3" seconds for a HTTP stream event with the JSON properties
+          | param | value |
+          | user  | alice |
+      And I wait up to "1" second without a HTTP stream event with the type "updated"
+      And the HTTP stream events must have been received in order
+          | id | type    |
+          | 1  | created |
+          | 2  | deleted |
+      And I close the HTTP stream
+
+  @http @stream
+  Scenario: Wait for NDJSON events
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/stream/records"
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["application/x-ndjson"]
+          },
+          "body": "{\"id\": 1, \"status\": \"pending\"}\n{\"id\": 2, \"status\": \"done\"}\n"
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/stream/records"
+     When I open a HTTP "GET" NDJSON stream
+     Then I wait up to "3" seconds for a HTTP stream event with the JSON properties
+          | param  | value       |
+          | id     | [NUMBER:2]  |
+          | status | done        |
+      And I wait up to "3" seconds for a HTTP stream event with the text
+      """
+      {"id": 1, "status": "pending"}
+      """
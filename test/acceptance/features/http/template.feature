@@ -0,0 +1,33 @@
+Feature: HTTP request templates
+
+  Background:
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/template/users/1"
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["application/json"]
+          },
+          "body": "{\"id\": 1, \"name\": \"alice\"}"
+        }
+      }
+      """
+
+  @http @template
+  Scenario: Send a HTTP request template
+    When I send the HTTP request template "users/get"
+    Then the HTTP response body must have the JSON properties
+      | property | value |
+      | name     | alice |
+
+  @http @template
+  Scenario: Send a HTTP request template with properties
+    When I send the HTTP request template "users/get" with the properties
+      | property                | value      |
+      | request.headers.X-Trace | golium     |
+      | response.json.id        | [NUMBER:1] |
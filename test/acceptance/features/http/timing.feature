@@ -0,0 +1,25 @@
+Feature: HTTP response time
+
+  @http @timing
+  Scenario: Check the response time of the HTTP requests
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "permanent": true,
+        "request": {
+          "method": "GET",
+          "path": "/timing/health"
+        },
+        "response": {
+          "status": 200
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/timing/health"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the HTTP response time must be less than "1000" millis
+      And the HTTP response first byte time must be less than "1000" millis
+     When I send "20" HTTP "GET" requests
+     Then the "p95" percentile of the HTTP response time must be less than "1000" millis
+      And the "50" percentile of the HTTP response connect time must be less than "500" millis
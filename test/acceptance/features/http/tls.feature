@@ -0,0 +1,58 @@
+Feature: HTTP TLS
+
+  @http @tls
+  Scenario: Send a HTTP request with the default TLS configuration
+    Given a mock HTTPS server "secure" is running
+      And I mock the HTTP request at "[CTXT:mock.secure.url]" for path "/tls" with status "200" and JSON body
+      """
+      {}
+      """
+      And the HTTP endpoint "[CTXT:mock.secure.url]/tls"
+      And the HTTP client CA certificates "[CTXT:mock.secure.certificate]"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the HTTP response TLS version must be "1.3"
+      And the HTTP server certificate subject must be "CN=localhost,O=golium mock"
+      And the HTTP server certificate must be valid for at least "300" days
+
+  @http @tls
+  Scenario: Send a HTTP request with minimum and maximum TLS versions
+    Given a mock HTTPS server "secure" is running
+      And I mock the HTTP request at "[CTXT:mock.secure.url]" for path "/tls" with status "200" and JSON body
+      """
+      {}
+      """
+      And the HTTP endpoint "[CTXT:mock.secure.url]/tls"
+      And the HTTP client CA certificates "[CTXT:mock.secure.certificate]"
+      And the HTTP client minimum TLS version "1.2"
+      And the HTTP client maximum TLS version "TLS 1.2"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the HTTP response TLS version must be "1.2"
+
+  @http @tls
+  Scenario: Send a HTTP request with a TLS configuration of the environment
+    Given a mock HTTPS server "secure" is running
+      And I mock the HTTP request at "[CTXT:mock.secure.url]" for path "/tls" with status "200" and JSON body
+      """
+      {}
+      """
+      And the HTTP endpoint "[CTXT:mock.secure.url]/tls"
+      And the HTTP client TLS configuration "legacy"
+      And the HTTP client CA certificates "[CTXT:mock.secure.certificate]"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the HTTP response TLS version must be "1.2"
+
+  @http @tls
+  Scenario: Send a HTTP request with mutual TLS
+    Given a mock HTTPS server "mutual" is running with mutual TLS
+      And I mock the HTTP request at "[CTXT:mock.mutual.url]" for path "/tls" with status "200" and JSON body
+      """
+      {}
+      """
+      And the HTTP endpoint "[CTXT:mock.mutual.url]/tls"
+      And the HTTP client CA certificates "[CTXT:mock.mutual.certificate]"
+      And the HTTP client certificate "[CTXT:mock.mutual.client-certificate]" and key "[CTXT:mock.mutual.client-key]"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
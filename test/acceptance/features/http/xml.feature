@@ -0,0 +1,55 @@
+Feature: HTTP XML body
+
+  Background:
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "POST",
+          "path": "/xml/users"
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["text/xml"]
+          },
+          "body": "<soap:Envelope xmlns:soap=\"http://schemas.xmlsoap.org/soap/envelope/\" xmlns:u=\"http://golium.test/users\"><soap:Body><u:user id=\"1\"><u:name>alice</u:name><u:role>admin</u:role><u:role>staff</u:role></u:user></soap:Body></soap:Envelope>"
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/xml/users"
+
+  @http @xml
+  Scenario: Send a XML request and validate the XML response
+    Given the HTTP request body with the XML
+      """
+      <GetUser><id>1</id></GetUser>
+      """
+     When I send a HTTP "POST" request
+     Then the HTTP status code must be "200"
+      And the HTTP response XML must have the properties
+        | property         | value  |
+        | //u:user/@id     | 1      |
+        | //u:user/u:name  | alice  |
+        | count(//u:role)  | 2      |
+        | //u:role[2]      | staff  |
+        | //u:user/u:email | [NULL] |
+      And I store the element "//u:user/u:name" from the XML HTTP response body in context "name"
+      And the HTTP response XML namespaces
+        | prefix | namespace                |
+        | users  | http://golium.test/users |
+      And the HTTP response XML must have the properties
+        | property     | value       |
+        | //users:name | [CTXT:name] |
+
+  @http @xml
+  Scenario: Send a SOAP request with text body
+    Given the HTTP request body with the content type "text/xml; charset=utf-8"
+      """
+      <soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"/>
+      """
+     When I send a HTTP "POST" request
+     Then the HTTP status code must be "200"
+      And the HTTP response XML must have the properties
+        | property          | value |
+        | //soap:Body/*/@id | 1     |
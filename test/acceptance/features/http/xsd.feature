@@ -0,0 +1,26 @@
+Feature: HTTP XML schema
+
+  # The XML schema validation requires libxml2 and building with the tag "xsd":
+  # go test -tags xsd ./test/acceptance --godog.tags=@xsd
+  @http @xsd
+  Scenario: Validate the XML response body against a XML schema
+    Given I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/xsd/users/1"
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["application/xml"]
+          },
+          "body": "<user id=\"1\"><name>alice</name><age>30</age></user>"
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/xsd/users/1"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the HTTP response body must comply with the XML schema "user"
@@ -227,3 +227,402 @@ Feature: HTTP Mock server
           """
           Just a plain text format
           """
+
+  @mockhttp
+  Scenario: Verify the requests received by the mock server
+    Given I store "[UUID]" in context "id"
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "permanent": true,
+        "request": {
+          "method": "POST",
+          "path": "/notify/[CTXT:id]"
+        },
+        "response": {
+          "status": 202
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/notify/[CTXT:id]"
+      And the HTTP request body with the JSON
+      """
+      {
+        "event": "paid",
+        "amount": 10
+      }
+      """
+     When I send a HTTP "POST" request
+     Then the HTTP status code must be "202"
+    Given the HTTP request body with the JSON
+      """
+      {
+        "event": "refunded",
+        "amount": 10
+      }
+      """
+     When I send a HTTP "POST" request
+     Then the HTTP status code must be "202"
+      And the mock server at "[CONF:httpMockUrl]" must have received "2" "POST" requests to "/notify/[CTXT:id]"
+      And the mock server at "[CONF:httpMockUrl]" must have received "1" "POST" request to "/notify/[CTXT:id]" with JSON properties
+          | param  | value       |
+          | event  | paid        |
+          | amount | [NUMBER:10] |
+      And the mock server at "[CONF:httpMockUrl]" must have received "0" "GET" requests to "/notify/[CTXT:id]"
+     When I reset the requests received by the mock server at "[CONF:httpMockUrl]"
+     Then the mock server at "[CONF:httpMockUrl]" must have received "0" "POST" requests to "/notify/[CTXT:id]"
+
+  @mockhttp
+  Scenario: Mock requests matching the query, the JSON body and a path template
+    Given I store "[UUID]" in context "id"
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "permanent": true,
+        "request": {
+          "method": "POST",
+          "path": "/payments/[CTXT:id]/{payment}",
+          "query": {
+            "currency": ["EUR"]
+          },
+          "json": {
+            "method": "card"
+          }
+        },
+        "response": {
+          "status": 201,
+          "body": "card"
+        }
+      }
+      """
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "permanent": true,
+        "priority": 1,
+        "request": {
+          "method": "POST",
+          "pathRegex": "/payments/[CTXT:id]/[0-9]+",
+          "bodyRegex": "\"method\":\\s*\"transfer\""
+        },
+        "response": {
+          "status": 201,
+          "body": "transfer"
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/payments/[CTXT:id]/1"
+      And the HTTP query parameters
+          | param    | value |
+          | currency | EUR   |
+      And the HTTP request body with the JSON
+      """
+      {"method": "card"}
+      """
+     When I send a HTTP "POST" request
+     Then the HTTP status code must be "201"
+      And the HTTP response body must be the text
+          """
+          card
+          """
+    Given the HTTP request body with the JSON
+      """
+      {"method": "transfer"}
+      """
+     When I send a HTTP "POST" request
+     Then the HTTP status code must be "201"
+      And the HTTP response body must be the text
+          """
+          transfer
+          """
+    Given the HTTP request body with the JSON
+      """
+      {"method": "cash"}
+      """
+     When I send a HTTP "POST" request
+     Then the HTTP status code must be "404"
+
+  @mockhttp
+  Scenario: Mock request with a response template
+    Given I store "[UUID]" in context "id"
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "POST",
+          "path": "/orders/[CTXT:id]/{order}"
+        },
+        "response": {
+          "status": 201,
+          "template": true,
+          "headers": {
+            "Content-Type": ["application/json"],
+            "X-Request-Id": ["[header:X-Request-Id]"]
+          },
+          "body": "{\"order\": \"[path:order]\", \"customer\": \"[json:customer.name]\", \"channel\": \"[query:channel]\", \"tracking\": \"[uuid]\"}"
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/orders/[CTXT:id]/1234"
+      And the HTTP query parameters
+          | param   | value |
+          | channel | web   |
+      And the HTTP request headers
+          | header       | value |
+          | X-Request-Id | r-1   |
+      And the HTTP request body with the JSON
+      """
+      {"customer": {"name": "alice"}}
+      """
+     When I send a HTTP "POST" request
+     Then the HTTP status code must be "201"
+      And the HTTP response must contain the headers
+          | param        | value |
+          | X-Request-Id | r-1   |
+      And the HTTP response body must have the JSON properties
+          | param    | value |
+          | order    | 1234  |
+          | customer | alice |
+          | channel  | web   |
+
+  @mockhttp
+  Scenario: Mock a sequence of responses
+    Given I store "[UUID]" in context "id"
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/retry/[CTXT:id]"
+        },
+        "responses": [
+          { "status": 503 },
+          { "status": 503 },
+          { "status": 200, "body": "ok" }
+        ]
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/retry/[CTXT:id]"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "503"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "503"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "404"
+
+  @mockhttp
+  Scenario: Mock a stateful scenario
+    Given I store "[UUID]" in context "id"
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "permanent": true,
+        "scenario": "[CTXT:id]",
+        "requiredState": "started",
+        "request": {
+          "method": "GET",
+          "path": "/accounts/[CTXT:id]"
+        },
+        "response": {
+          "status": 404
+        }
+      }
+      """
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "scenario": "[CTXT:id]",
+        "requiredState": "started",
+        "newState": "created",
+        "request": {
+          "method": "PUT",
+          "path": "/accounts/[CTXT:id]"
+        },
+        "response": {
+          "status": 201
+        }
+      }
+      """
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "permanent": true,
+        "scenario": "[CTXT:id]",
+        "requiredState": "created",
+        "request": {
+          "method": "GET",
+          "path": "/accounts/[CTXT:id]"
+        },
+        "response": {
+          "status": 200
+        }
+      }
+      """
+      And the HTTP endpoint "[CONF:httpMockUrl]/accounts/[CTXT:id]"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "404"
+      And the mock server at "[CONF:httpMockUrl]" must have the scenario "[CTXT:id]" in state "started"
+     When I send a HTTP "PUT" request
+     Then the HTTP status code must be "201"
+      And the mock server at "[CONF:httpMockUrl]" must have the scenario "[CTXT:id]" in state "created"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+
+  @mockhttp
+  Scenario: Mock request without response
+    Given I store "[UUID]" in context "id"
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/test/[CTXT:id]"
+        },
+        "latency": -1
+      }
+      """
+    Given the HTTP endpoint "[CONF:httpMockUrl]/test/[CTXT:id]"
+      And an HTTP timeout of "300" milliseconds
+     When I send a HTTP "GET" request
+     Then the HTTP response timed out
+
+  @mockhttp
+  Scenario: Mock request with an error fault
+    Given I store "[UUID]" in context "id"
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/test/[CTXT:id]"
+        },
+        "response": {
+          "status": 200
+        },
+        "fault": {
+          "type": "error",
+          "status": 503
+        }
+      }
+      """
+    Given the HTTP endpoint "[CONF:httpMockUrl]/test/[CTXT:id]"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "503"
+
+  @mockhttp
+  Scenario: Start a mock server for the scenario
+    Given a mock HTTP server "payments" is running
+      And I mock the HTTP request at "[CTXT:mock.payments.url]" for path "/payments" with status "200" and JSON body
+      """
+      {
+        "status": "paid"
+      }
+      """
+    Given the HTTP endpoint "[CTXT:mock.payments.url]/payments"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the HTTP response body must have the JSON properties
+          | param  | value |
+          | status | paid  |
+      And the mock server at "[CTXT:mock.payments.url]" must have received "1" "GET" request to "/payments"
+      And the mock server at "[CONF:httpMockUrl]" must have received "0" "GET" requests to "/payments"
+
+  @mockhttp
+  Scenario: Start a mock server with mappings for the scenario
+    Given a mock HTTP server "payments" is running with the mappings "./test_data/mappings"
+      And the HTTP endpoint "[CTXT:mock.payments.url]/payments/p1"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the HTTP response body must have the JSON properties
+          | param  | value |
+          | id     | p1    |
+          | status | paid  |
+      And I stop the mock HTTP server "payments"
+
+  @mockhttp
+  Scenario: Proxy the unmatched requests and modify a proxied response
+    Given I store "[UUID]" in context "id"
+      And I mock the HTTP request at "[CONF:httpMockUrl]" with the JSON
+      """
+      {
+        "permanent": true,
+        "request": {
+          "method": "GET",
+          "path": "/test/[CTXT:id]"
+        },
+        "response": {
+          "status": 200,
+          "headers": {
+            "Content-Type": ["application/json"]
+          },
+          "body": "{\"status\": \"pending\", \"amount\": 10}"
+        }
+      }
+      """
+      And a mock HTTP server "payments" is running
+      And the mock HTTP server "payments" proxies the unmatched requests to "[CONF:httpMockUrl]"
+    Given the HTTP endpoint "[CTXT:mock.payments.url]/test/[CTXT:id]"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the HTTP response body must have the JSON properties
+          | param  | value   |
+          | status | pending |
+    Given I mock the HTTP request at "[CTXT:mock.payments.url]" with the JSON
+      """
+      {
+        "request": {
+          "method": "GET",
+          "path": "/test/[CTXT:id]"
+        },
+        "proxy": {
+          "status": 202,
+          "json": {
+            "status": "paid"
+          }
+        }
+      }
+      """
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "202"
+      And the HTTP response body must have the JSON properties
+          | param  | value       |
+          | status | paid        |
+          | amount | [NUMBER:10] |
+      And the mock server at "[CONF:httpMockUrl]" must have received "2" "GET" requests to "/test/[CTXT:id]"
+
+  @mockhttp
+  Scenario: Mock request over HTTPS
+    Given a mock HTTPS server "secure" is running
+      And I mock the HTTP request at "[CTXT:mock.secure.url]" for path "/secure" with status "200" and JSON body
+      """
+      {
+        "value": "secure"
+      }
+      """
+    Given the HTTP endpoint "[CTXT:mock.secure.url]/secure"
+      And the HTTP client CA certificates "[CTXT:mock.secure.certificate]"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the HTTP response body must have the JSON properties
+          | param | value  |
+          | value | secure |
+      And the mock server at "[CTXT:mock.secure.url]" must have received "1" "GET" request to "/secure"
+
+  @mockhttp
+  Scenario: Mock request over mutual TLS
+    Given a mock HTTPS server "mutual" is running with mutual TLS
+      And I mock the HTTP request at "[CTXT:mock.mutual.url]" for path "/mutual" with status "200" and JSON body
+      """
+      {
+        "value": "mutual"
+      }
+      """
+    Given the HTTP endpoint "[CTXT:mock.mutual.url]/mutual"
+      And the HTTP client CA certificates "[CTXT:mock.mutual.certificate]"
+      And the HTTP client certificate "[CTXT:mock.mutual.client-certificate]" and key "[CTXT:mock.mutual.client-key]"
+     When I send a HTTP "GET" request
+     Then the HTTP status code must be "200"
+      And the mock server at "[CTXT:mock.mutual.url]" must have received "1" "GET" request to "/mutual"
+      And I reset the mock server at "[CTXT:mock.mutual.url]"
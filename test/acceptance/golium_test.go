@@ -19,12 +19,16 @@ import (
 	"os"
 	"testing"
 
+	log "github.com/sirupsen/logrus"
+
 	"github.com/TelefonicaTC2Tech/golium"
 	mockhttp "github.com/TelefonicaTC2Tech/golium/mock/http"
 	"github.com/TelefonicaTC2Tech/golium/steps/common"
 	"github.com/TelefonicaTC2Tech/golium/steps/dns"
 	"github.com/TelefonicaTC2Tech/golium/steps/elasticsearch"
+	"github.com/TelefonicaTC2Tech/golium/steps/graphql"
 	"github.com/TelefonicaTC2Tech/golium/steps/http"
+	"github.com/TelefonicaTC2Tech/golium/steps/http/har"
 	"github.com/TelefonicaTC2Tech/golium/steps/jwt"
 	"github.com/TelefonicaTC2Tech/golium/steps/rabbit"
 	"github.com/TelefonicaTC2Tech/golium/steps/redis"
@@ -37,18 +41,35 @@ import (
 
 func TestMain(m *testing.M) {
 	launcher := golium.NewLauncher()
-	InitializeMocks()
 	launcher.Launch(InitializeTestSuite, InitializeScenario)
 	exitVal := m.Run()
 	os.Exit(exitVal)
 }
 
-func InitializeMocks() {
+// InitializeMocks starts the shared mock server at [CONF:httpMockUrl].
+// The scenarios might start their own mock servers with the step
+// 'a mock HTTP server "name" is running'.
+func InitializeMocks() *mockhttp.Server {
 	server := mockhttp.NewServer(9000)
+	if err := server.Listen(); err != nil {
+		log.Fatal(err)
+	}
 	go server.Start()
+	return server
 }
 
+// InitializeTestSuite starts the shared mock server before the suite and stops it after
+// the suite, because the launcher exits when the suite ends.
 func InitializeTestSuite(ctx context.Context, suiteCtx *godog.TestSuiteContext) {
+	var server *mockhttp.Server
+	suiteCtx.BeforeSuite(func() {
+		server = InitializeMocks()
+	})
+	suiteCtx.AfterSuite(func() {
+		if err := server.Stop(ctx); err != nil {
+			log.Errorf("Failed stopping the mock server: %s", err)
+		}
+	})
 }
 
 func InitializeScenario(ctx context.Context, scenarioCtx *godog.ScenarioContext) {
@@ -61,7 +82,9 @@ func InitializeScenario(ctx context.Context, scenarioCtx *godog.ScenarioContext)
 		mockhttp.Steps{},
 		elasticsearch.Steps{},
 		s3steps.Steps{},
+		har.Steps{},
 		http.Steps{},
+		graphql.Steps{},
 		shared.Steps{},
 		aggregated.Steps{},
 	}
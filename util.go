@@ -14,6 +14,11 @@
 
 package golium
 
+import (
+	"os"
+	"strings"
+)
+
 // ContainsString check if a expected value is included in a slice of values.
 func ContainsString(expected string, values []string) bool {
 	for _, value := range values {
@@ -23,3 +28,12 @@ func ContainsString(expected string, values []string) bool {
 	}
 	return false
 }
+
+// LoadPEM returns the PEM content of a value (e.g. a certificate or a key).
+// If the value is not a PEM block, it is considered a file path.
+func LoadPEM(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return os.ReadFile(value)
+}
@@ -14,7 +14,11 @@
 
 package golium
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestContainsString(t *testing.T) {
 	list := []string{"attribute1", "attribute2", "attribute3"}
@@ -53,3 +57,20 @@ func TestContainsString(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadPEM(t *testing.T) {
+	pem := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(path, []byte(pem), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range []string{pem, path} {
+		got, err := LoadPEM(value)
+		if err != nil || string(got) != pem {
+			t.Errorf("LoadPEM() = %q, %v, expected %q", got, err, pem)
+		}
+	}
+	if _, err := LoadPEM(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("LoadPEM() expected error for a missing file")
+	}
+}
@@ -33,6 +33,17 @@ func ValueAsString(ctx context.Context, s string) string {
 	return fmt.Sprintf("%v", Value(ctx, s))
 }
 
+// ValueAsStringOrEmpty invokes Value and converts the return value to string.
+// It returns an empty string if the value is nil (e.g. an optional [CONF:xxx] parameter
+// that is not configured).
+func ValueAsStringOrEmpty(ctx context.Context, s string) string {
+	v := Value(ctx, s)
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
 // ValueAsInt invokes Value and converts the return value to int.
 func ValueAsInt(ctx context.Context, s string) (int, error) {
 	v := Value(ctx, s)
@@ -99,3 +99,19 @@ func TestTagWithoutChecks(t *testing.T) {
 		_ = golium.NewComposedTag(s).Value(ctx)
 	}
 }
+
+func TestValueAsStringOrEmpty(t *testing.T) {
+	ctx := context.Background()
+	tcs := map[string]string{
+		"[NULL]":        "",
+		"[EMPTY]":       "",
+		"[TRUE]":        "true",
+		"[NUMBER:1234]": "1234",
+		"text":          "text",
+	}
+	for s, expected := range tcs {
+		if actual := golium.ValueAsStringOrEmpty(ctx, s); actual != expected {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+	}
+}